@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+	"github.com/laysdragon/go-docker-dev-swap/internal/local"
+)
+
+// artifactDebounce 目錄 artifact 最後一次變更後等待多久才同步
+const artifactDebounce = 500 * time.Millisecond
+
+// artifactSync 將一個 artifact（或 kind: sync 的同步目錄）同步到遠端工作目錄
+// 記錄每個檔案上次上傳時的大小、修改時間與權限，之後只上傳有變動的檔案，並刪除本地已不存在的檔案
+type artifactSync struct {
+	exec     executor.Executor
+	artifact config.Artifact
+	remote   string
+	label    string   // 用於日誌與錯誤訊息
+	ignore   []string // 目錄中不同步的 glob
+	debounce time.Duration
+
+	mu     sync.Mutex
+	isDir  bool
+	synced map[string]syncedFile // 相對路徑（檔案 artifact 為空字串）→ 上次上傳時的狀態
+	ready  bool                  // 遠端目錄是否已建立
+}
+
+type syncedFile struct {
+	size    int64
+	modTime time.Time
+	mode    os.FileMode
+}
+
+// newArtifactSync 建立 artifact 的同步，單一檔案存放在以名稱命名的遠端目錄中（見 GetRemoteArtifactFilePath）
+func newArtifactSync(exec executor.Executor, rc *config.RuntimeConfig, artifact config.Artifact) *artifactSync {
+	remote := rc.GetRemoteArtifactPath(artifact.Name)
+	if artifact.IsFile() {
+		remote = rc.GetRemoteArtifactFilePath(artifact)
+	}
+	return &artifactSync{
+		exec:     exec,
+		artifact: artifact,
+		remote:   remote,
+		label:    fmt.Sprintf("artifact '%s'", artifact.Name),
+		debounce: artifactDebounce,
+		synced:   make(map[string]syncedFile),
+	}
+}
+
+// newDirSync 建立 kind: sync 的目錄同步，檔案保留本地權限
+func newDirSync(exec executor.Executor, rc *config.RuntimeConfig) *artifactSync {
+	sync := rc.Component.Sync
+	return &artifactSync{
+		exec:     exec,
+		artifact: config.Artifact{Name: "sync", LocalPath: sync.LocalDir, ContainerPath: sync.ContainerDir},
+		remote:   rc.GetRemoteSyncPath(),
+		label:    "同步目錄",
+		ignore:   sync.Ignore,
+		debounce: sync.Debounce,
+		synced:   make(map[string]syncedFile),
+	}
+}
+
+// IsDir 返回 artifact 是否為目錄（第一次同步後有效）
+func (a *artifactSync) IsDir() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.isDir
+}
+
+// Sync 同步 artifact，返回上傳與刪除的檔案數
+func (a *artifactSync) Sync(ctx context.Context) (uploaded, removed int, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.artifact.LocalPath)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s %s 無法讀取: %w", a.label, a.artifact.LocalPath, err)
+	}
+	a.isDir = info.IsDir()
+
+	// 空目錄也需要先建立，否則掛載時會由 docker 以 root 建立
+	// 單一檔案的存放目錄在舊版本中是檔案本身，存在時先移除
+	if !a.ready {
+		cmd := "mkdir -p -- " + shellQuote(a.remote)
+		if !a.isDir {
+			dir := shellQuote(path.Dir(a.remote))
+			cmd = fmt.Sprintf("{ [ -d %s ] || rm -f -- %s; } && mkdir -p -- %s", dir, dir, dir)
+		}
+		if _, err := a.exec.Execute(ctx, cmd); err != nil {
+			return 0, 0, fmt.Errorf("建立%s的遠端目錄失敗: %w", a.label, err)
+		}
+		a.ready = true
+	}
+
+	current := make(map[string]syncedFile)
+	if a.isDir {
+		err = filepath.WalkDir(a.artifact.LocalPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(a.artifact.LocalPath, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if rel != "." && local.MatchGlobs(a.ignore, rel) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			fi, err := os.Stat(p) // 跟隨符號連結
+			if err != nil || !fi.Mode().IsRegular() {
+				return nil
+			}
+			current[rel] = a.fileState(fi)
+			return nil
+		})
+		if err != nil {
+			return 0, 0, fmt.Errorf("讀取%s失敗: %w", a.label, err)
+		}
+	} else {
+		current[""] = a.fileState(info)
+	}
+
+	rels := make([]string, 0, len(current))
+	for rel := range current {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+	for _, rel := range rels {
+		state := current[rel]
+		if prev, ok := a.synced[rel]; ok && prev.size == state.size && prev.modTime.Equal(state.modTime) && prev.mode == state.mode {
+			continue
+		}
+		if err := a.exec.UploadFileMode(ctx, a.localPath(rel), a.remotePath(rel), state.mode); err != nil {
+			return uploaded, removed, fmt.Errorf("上傳%s的 %s 失敗: %w", a.label, a.localPath(rel), err)
+		}
+		a.synced[rel] = state
+		uploaded++
+	}
+
+	var stale []string
+	for rel := range a.synced {
+		if _, ok := current[rel]; !ok {
+			stale = append(stale, rel)
+		}
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		quoted := make([]string, len(stale))
+		for i, rel := range stale {
+			quoted[i] = shellQuote(a.remotePath(rel))
+		}
+		if _, err := a.exec.Execute(ctx, "rm -f -- "+strings.Join(quoted, " ")); err != nil {
+			return uploaded, removed, fmt.Errorf("刪除%s已移除的檔案失敗: %w", a.label, err)
+		}
+		for _, rel := range stale {
+			delete(a.synced, rel)
+		}
+		removed = len(stale)
+	}
+	return uploaded, removed, nil
+}
+
+func (a *artifactSync) fileState(info os.FileInfo) syncedFile {
+	return syncedFile{
+		size:    info.Size(),
+		modTime: info.ModTime(),
+		mode:    a.artifact.FileMode(info.Mode()),
+	}
+}
+
+func (a *artifactSync) localPath(rel string) string {
+	if rel == "" {
+		return a.artifact.LocalPath
+	}
+	return filepath.Join(a.artifact.LocalPath, filepath.FromSlash(rel))
+}
+
+func (a *artifactSync) remotePath(rel string) string {
+	if rel == "" {
+		return a.remote
+	}
+	return path.Join(a.remote, rel)
+}
+
+// syncArtifacts 部署前同步所有 artifacts
+func syncArtifacts(ctx context.Context, syncs []*artifactSync) error {
+	for _, s := range syncs {
+		uploaded, _, err := s.Sync(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("%s 已上傳 %d 個檔案 → %s", s.label, uploaded, s.artifact.ContainerPath)
+	}
+	return nil
+}
+
+// watchArtifact 監控 artifact 的變更並同步，需要重啟時呼叫 restart
+func watchArtifact(ctx context.Context, s *artifactSync, restart func(reason string)) error {
+	onChange := func() {
+		uploaded, removed, err := s.Sync(ctx)
+		if err != nil {
+			log.Printf("同步失敗: %v", err)
+			return
+		}
+		if uploaded == 0 && removed == 0 {
+			return
+		}
+		log.Printf("%s 已同步：上傳 %d 個、刪除 %d 個檔案", s.label, uploaded, removed)
+		if s.artifact.RestartOnChange() {
+			restart(fmt.Sprintf("%s 已更新", s.label))
+		}
+	}
+
+	if s.IsDir() {
+		watcher := local.NewSourceWatcher(s.artifact.LocalPath, []string{"*"}, s.ignore, s.debounce, func([]string) { onChange() })
+		return watcher.Start(ctx)
+	}
+	watcher := local.NewFileWatcher(s.artifact.LocalPath, func(string) { onChange() })
+	return watcher.Start(ctx)
+}
+
+// shellQuote 以單引號包裹字串，供在執行主機的 shell 中使用
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
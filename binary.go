@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+	"github.com/laysdragon/go-docker-dev-swap/internal/local"
+)
+
+// binaryValidator 在上傳前檢查本地執行檔能否在目標容器執行與調試
+// 啟動時與每次檔案更新都會檢查；已確認存在於容器內的動態庫會被記住，避免每次都進入容器搜尋
+type binaryValidator struct {
+	dockerMgr *docker.Manager
+	rc        *config.RuntimeConfig
+	platform  local.Platform
+
+	mu    sync.Mutex
+	found map[string]bool
+}
+
+func newBinaryValidator(dockerMgr *docker.Manager, rc *config.RuntimeConfig, platform local.Platform) *binaryValidator {
+	return &binaryValidator{
+		dockerMgr: dockerMgr,
+		rc:        rc,
+		platform:  platform,
+		found:     make(map[string]bool),
+	}
+}
+
+// Check 檢查執行檔，警告只輸出日誌，無法執行或調試時返回錯誤
+// container 為用來搜尋動態庫的運行中容器，為空時略過動態庫檢查
+func (v *binaryValidator) Check(ctx context.Context, container string) error {
+	report, err := local.ValidateBinary(v.rc.Component.LocalBinary, local.BinaryTarget{
+		Platform: v.platform,
+		Debugger: v.rc.Component.DlvConfig != nil && v.rc.Component.DlvConfig.Enabled,
+	})
+	if err != nil {
+		return err
+	}
+	for _, warning := range report.Warnings {
+		log.Printf("警告: %s", warning)
+	}
+
+	if report.Interpreter == "" || container == "" {
+		return nil
+	}
+	missing, err := v.missingLibraries(ctx, container, report.Interpreter, report.Needed)
+	if err != nil {
+		log.Printf("無法檢查容器內的動態庫，略過: %v", err)
+		return nil
+	}
+	if len(missing) > 0 {
+		log.Printf("警告: 容器內找不到執行檔需要的動態庫: %s；請以 CGO_ENABLED=0 編譯，或在 initial_scripts 安裝", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func (v *binaryValidator) missingLibraries(ctx context.Context, container, interpreter string, needed []string) ([]string, error) {
+	v.mu.Lock()
+	if v.found[interpreter] {
+		interpreter = ""
+	}
+	var libs []string
+	for _, lib := range needed {
+		if !v.found[lib] {
+			libs = append(libs, lib)
+		}
+	}
+	v.mu.Unlock()
+	if interpreter == "" && len(libs) == 0 {
+		return nil, nil
+	}
+
+	missing, err := v.dockerMgr.MissingLibraries(ctx, container, interpreter, libs)
+	if err != nil {
+		return nil, err
+	}
+
+	isMissing := make(map[string]bool, len(missing))
+	for _, name := range missing {
+		isMissing[name] = true
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, name := range append(libs, interpreter) {
+		if name != "" && !isMissing[name] {
+			v.found[name] = true
+		}
+	}
+	return missing, nil
+}
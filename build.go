@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/local"
+	"github.com/laysdragon/go-docker-dev-swap/internal/tui"
+)
+
+// buildRunner 執行 component 的內建編譯步驟，編譯成功才部署
+// 編譯進行中收到的變更會合併為一次重新編譯，並略過過時版本的部署
+type buildRunner struct {
+	build   *config.BuildConfig
+	remote  *remoteBuilder // build.strategy 為 remote 時不為 nil
+	update  func(tui.BuildStatus)
+	trigger chan string
+}
+
+// newBuildRunner 建立編譯步驟，remote 為 nil 時在本機編譯；update 為 nil 時只輸出日誌
+func newBuildRunner(build *config.BuildConfig, remote *remoteBuilder, update func(tui.BuildStatus)) *buildRunner {
+	return &buildRunner{
+		build:   build,
+		remote:  remote,
+		update:  update,
+		trigger: make(chan string, 1),
+	}
+}
+
+// Build 同步執行一次編譯，成功時返回 nil
+func (b *buildRunner) Build(ctx context.Context) error {
+	b.setStatus(tui.BuildStatus{Phase: tui.BuildRunning})
+
+	var (
+		result *local.BuildResult
+		err    error
+	)
+	if b.remote != nil {
+		log.Printf("在建置容器 %s 中編譯: %s", b.remote.Image(), b.build.Command)
+		result, err = b.remote.Build(ctx)
+	} else {
+		log.Printf("執行編譯: %s", b.build.Command)
+		result, err = local.RunBuild(ctx, b.build.Command, b.build.WorkDir, b.build.Env)
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lines := outputLines(result.Output)
+		// 遠端編譯的輸出已即時寫入日誌
+		if b.remote == nil {
+			for _, line := range lines {
+				log.Printf("  %s", line)
+			}
+		}
+		b.setStatus(tui.BuildStatus{Phase: tui.BuildFailed, Output: lines, Duration: result.Duration})
+		return err
+	}
+
+	b.setStatus(tui.BuildStatus{Phase: tui.BuildSucceeded, Duration: result.Duration})
+	log.Printf("編譯成功 (%s)", result.Duration.Round(time.Millisecond))
+	return nil
+}
+
+// Trigger 要求重新編譯，不會阻塞；編譯進行中時會在結束後再編譯一次
+func (b *buildRunner) Trigger(reason string) {
+	select {
+	case b.trigger <- reason:
+	default:
+	}
+}
+
+// Run 處理編譯要求直到 ctx 結束，編譯成功後呼叫 deploy
+func (b *buildRunner) Run(ctx context.Context, deploy func()) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case reason := <-b.trigger:
+			log.Printf("%s，重新編譯...", reason)
+			if err := b.Build(ctx); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("%v，修正後會自動重新編譯", err)
+				}
+				continue
+			}
+			if len(b.trigger) > 0 {
+				log.Println("編譯期間有新的變更，略過此版本的部署")
+				continue
+			}
+			deploy()
+		}
+	}
+}
+
+// OnSourceChange 作為原始碼監控的回呼
+func (b *buildRunner) OnSourceChange(changed []string) {
+	if len(changed) > 3 {
+		b.Trigger(fmt.Sprintf("偵測到原始碼變更: %s 等 %d 個檔案", strings.Join(changed[:3], ", "), len(changed)))
+		return
+	}
+	b.Trigger("偵測到原始碼變更: " + strings.Join(changed, ", "))
+}
+
+func (b *buildRunner) setStatus(status tui.BuildStatus) {
+	if b.update != nil {
+		b.update(status)
+	}
+}
+
+// outputLines 將編譯輸出拆成行，略過空行
+func outputLines(output string) []string {
+	var lines []string
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/delve"
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+)
+
+// debuggerMonitor 透過 IDE 使用的本地地址探測 Delve 是否已可接受連線
+// 每次部署或切換 debugger 後重新探測，結果輸出到工作日誌與 TUI
+//
+// dlv 以暫停狀態啟動（不使用 --continue），就緒後先恢復重啟前保存的斷點再繼續執行目標程式，
+// 避免 IDE 來不及重新設定斷點時程式已經執行過斷點位置；
+// 啟用 wait_for_debugger 時目標程式保持暫停，直到 IDE 連接後繼續或使用者手動繼續；
+// 未啟用時即使探測或恢復斷點失敗也會繼續執行目標程式，不讓程式停在暫停狀態
+type debuggerMonitor struct {
+	addr      string
+	dockerMgr *docker.Manager
+	rc        *config.RuntimeConfig
+	update    func(delve.Status)
+
+	mu          sync.Mutex
+	cancel      context.CancelFunc
+	ready       bool
+	breakpoints []delve.Breakpoint
+	launchedAt  time.Time // supervisor 最後一次重新啟動程式的時間（主機時鐘）
+}
+
+const (
+	// breakpointCallTimeout 保存 / 恢復斷點與繼續執行時單次 RPC 的逾時
+	breakpointCallTimeout = 5 * time.Second
+	// pausedPollInterval 暫停等待連接時檢查目標程式是否已被繼續執行的間隔
+	pausedPollInterval = time.Second
+	// resumeRetryWindow 探測失敗後以 pausedPollInterval 密集重試繼續執行的時間，之後改為 resumeRetryInterval
+	resumeRetryWindow = 30 * time.Second
+	// resumeRetryInterval 超過 resumeRetryWindow 後重試繼續執行的間隔，直到成功、重新部署或程式退出
+	resumeRetryInterval = 5 * time.Second
+)
+
+// newDebuggerMonitor 建立 debugger 就緒探測，update 為 nil 時只輸出日誌
+func newDebuggerMonitor(addr string, dockerMgr *docker.Manager, rc *config.RuntimeConfig, update func(delve.Status)) *debuggerMonitor {
+	return &debuggerMonitor{
+		addr:      addr,
+		dockerMgr: dockerMgr,
+		rc:        rc,
+		update:    update,
+	}
+}
+
+// Recheck 取消進行中的探測並重新開始；debugger 未啟用時只更新狀態
+func (d *debuggerMonitor) Recheck(ctx context.Context) {
+	d.mu.Lock()
+	if d.cancel != nil {
+		d.cancel()
+		d.cancel = nil
+	}
+	d.ready = false
+	d.mu.Unlock()
+
+	if !d.rc.Component.DlvConfig.Enabled {
+		d.setStatus(delve.Status{Phase: delve.PhaseDisabled, Addr: d.addr})
+		return
+	}
+
+	// 在部署時讀取，TUI 切換只影響下次部署
+	waitForDebugger := d.rc.Component.DlvConfig.WaitForDebugger
+	probeCtx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.mu.Unlock()
+
+	d.setStatus(delve.Status{Phase: delve.PhaseStarting, Addr: d.addr})
+	log.Printf("等待 Delve 就緒 (%s)...", d.addr)
+
+	// dlv dap 只接受一個連線，中斷後即結束，無法以連線探測，改為檢查容器日誌
+	if d.rc.Component.DlvConfig.Mode == config.DlvModeDAP {
+		go func() {
+			defer cancel()
+			d.waitDAPReady(probeCtx)
+		}()
+		return
+	}
+
+	go func() {
+		defer cancel()
+		version, err := delve.WaitReady(probeCtx, d.addr, delve.ProbeOptions{
+			Timeout: d.rc.Component.DlvConfig.ReadyTimeout,
+			Check:   d.checkContainer,
+		})
+		if probeCtx.Err() != nil {
+			// 已被新的探測取代或程式正在退出
+			return
+		}
+		if err != nil {
+			d.setStatus(delve.Status{Phase: delve.PhaseFailed, Addr: d.addr, Reason: err.Error()})
+			log.Printf("Debugger 啟動失敗: %v", err)
+			if !waitForDebugger {
+				d.resumeAfterFailure(probeCtx)
+			}
+			return
+		}
+		if err := d.restore(probeCtx); err != nil {
+			if probeCtx.Err() != nil {
+				return
+			}
+			log.Printf("恢復斷點失敗，直接繼續執行: %v", err)
+		}
+		if !waitForDebugger {
+			if err := d.resume(probeCtx); err != nil {
+				if probeCtx.Err() != nil {
+					return
+				}
+				d.setStatus(delve.Status{Phase: delve.PhaseFailed, Addr: d.addr, Reason: err.Error()})
+				log.Printf("Debugger 啟動失敗: %v", err)
+				d.resumeAfterFailure(probeCtx)
+				return
+			}
+		}
+
+		d.mu.Lock()
+		d.ready = true
+		d.mu.Unlock()
+
+		if waitForDebugger {
+			d.setStatus(delve.Status{Phase: delve.PhasePaused, Addr: d.addr, Version: version})
+			log.Printf("目標程式已暫停，等待 debugger 連接 %s (Delve %s，目標程式 %s)", d.addr, version.DelveVersion, version.TargetGoVersion)
+			d.waitResumed(probeCtx)
+			if probeCtx.Err() != nil {
+				return
+			}
+		}
+		d.setStatus(delve.Status{Phase: delve.PhaseReady, Addr: d.addr, Version: version})
+		log.Printf("Debugger 已就緒，可在 %s 連接 (Delve %s，目標程式 %s)", d.addr, version.DelveVersion, version.TargetGoVersion)
+	}()
+}
+
+// Relaunched 記錄 supervisor 在容器內重新啟動程式的時間，下次探測只檢查此時間之後的日誌
+func (d *debuggerMonitor) Relaunched(at time.Time) {
+	d.mu.Lock()
+	d.launchedAt = at
+	d.mu.Unlock()
+}
+
+// dapListeningMarker dlv dap 開始監聽時輸出的訊息
+const dapListeningMarker = "DAP server listening at"
+
+// waitDAPReady 等待容器日誌出現 dlv dap 的監聽訊息
+func (d *debuggerMonitor) waitDAPReady(ctx context.Context) {
+	probeCtx := ctx
+	if timeout := d.rc.Component.DlvConfig.ReadyTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		probeCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	fail := func(reason string) {
+		d.setStatus(delve.Status{Phase: delve.PhaseFailed, Addr: d.addr, Reason: reason})
+		log.Printf("Debugger 啟動失敗: %s", reason)
+	}
+
+	d.mu.Lock()
+	since := d.launchedAt
+	d.mu.Unlock()
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		found, err := d.dockerMgr.CurrentRunLogsContain(probeCtx, d.rc.GetDevContainerName(), dapListeningMarker, since)
+		if err == nil && found {
+			d.setStatus(delve.Status{Phase: delve.PhaseReady, Addr: d.addr})
+			log.Printf("DAP server 已就緒，編輯器可在 %s 連接（launch 的 program 為 %s）", d.addr, d.rc.GetStagedBinaryPath())
+			return
+		}
+		if probeCtx.Err() == nil {
+			if err := d.checkContainer(probeCtx); err != nil {
+				fail(err.Error())
+				return
+			}
+		}
+
+		select {
+		case <-probeCtx.Done():
+			if ctx.Err() == nil {
+				fail(fmt.Sprintf("dlv dap 在 %s 內未開始監聽，請查看容器日誌", d.rc.Component.DlvConfig.ReadyTimeout))
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Resume 繼續執行暫停等待連接的目標程式，供不使用 IDE 時手動繼續
+func (d *debuggerMonitor) Resume(ctx context.Context) error {
+	if !d.rc.Component.DlvConfig.Enabled {
+		return errors.New("debugger 未啟用")
+	}
+	if d.rc.Component.DlvConfig.Mode == config.DlvModeDAP {
+		return errors.New("DAP 模式下目標程式由編輯器啟動與控制")
+	}
+	return d.resume(ctx)
+}
+
+// SetWaitForDebugger 設定目標程式啟動後是否保持暫停，下次部署時生效
+func (d *debuggerMonitor) SetWaitForDebugger(enabled bool) {
+	d.rc.Component.DlvConfig.WaitForDebugger = enabled
+	if d.rc.Component.DlvConfig.Mode == config.DlvModeDAP {
+		log.Println("DAP 模式下目標程式一律由編輯器啟動，等待連接設定不影響執行")
+		return
+	}
+	if enabled {
+		log.Println("下次部署後目標程式將暫停，等待 debugger 連接")
+	} else {
+		log.Println("下次部署後目標程式將直接執行")
+	}
+}
+
+func (d *debuggerMonitor) resume(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, breakpointCallTimeout)
+	defer cancel()
+
+	client, err := delve.Dial(ctx, d.addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Continue(); err != nil {
+		return fmt.Errorf("繼續執行目標程式失敗: %w", err)
+	}
+	return nil
+}
+
+// resumeAfterFailure 探測或繼續執行失敗後（例如 tunnel 暫時中斷）持續嘗試繼續執行目標程式，直到成功或 ctx 結束（重新部署或程式退出）
+// dlv 不使用 --continue 啟動，成功前程式會停在暫停狀態；超過 resumeRetryWindow 後以 PhasePaused 狀態與原因提示使用者
+func (d *debuggerMonitor) resumeAfterFailure(ctx context.Context) {
+	deadline := time.Now().Add(resumeRetryWindow)
+	interval := pausedPollInterval
+	reported := false
+	for {
+		if d.targetRunning(ctx) {
+			d.resumedAfterFailure()
+			return
+		}
+		err := d.resume(ctx)
+		if err == nil {
+			log.Println("已繼續執行目標程式（斷點可能未恢復）")
+			d.resumedAfterFailure()
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if !reported && time.Now().After(deadline) {
+			reported = true
+			interval = resumeRetryInterval
+			reason := fmt.Sprintf("無法繼續執行目標程式，程式仍處於暫停狀態（每 %s 重試，或以 IDE 連接後繼續）: %v", resumeRetryInterval, err)
+			d.setStatus(delve.Status{Phase: delve.PhasePaused, Addr: d.addr, Reason: reason})
+			log.Print(reason)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// resumedAfterFailure 失敗後成功繼續執行時標記 debugger 可用
+func (d *debuggerMonitor) resumedAfterFailure() {
+	d.mu.Lock()
+	d.ready = true
+	d.mu.Unlock()
+	d.setStatus(delve.Status{Phase: delve.PhaseReady, Addr: d.addr})
+}
+
+// waitResumed 等待目標程式被 IDE 或 Resume 繼續執行
+func (d *debuggerMonitor) waitResumed(ctx context.Context) {
+	ticker := time.NewTicker(pausedPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if d.targetRunning(ctx) {
+			return
+		}
+	}
+}
+
+func (d *debuggerMonitor) targetRunning(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, breakpointCallTimeout)
+	defer cancel()
+
+	client, err := delve.Dial(ctx, d.addr)
+	if err != nil {
+		return false
+	}
+	defer client.Close()
+
+	state, err := client.State(ctx)
+	return err == nil && state.Running
+}
+
+// Snapshot 在重啟開發容器前保存目前 Delve 上的斷點
+// 讀取斷點前需要暫停目標程式；失敗時保留上一次保存的斷點
+func (d *debuggerMonitor) Snapshot(ctx context.Context) {
+	d.mu.Lock()
+	ready := d.ready
+	d.mu.Unlock()
+	// DAP 模式下斷點由編輯器在每次 launch 時設定
+	if !ready || !d.rc.Component.DlvConfig.Enabled || d.rc.Component.DlvConfig.Mode == config.DlvModeDAP {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, breakpointCallTimeout)
+	defer cancel()
+
+	breakpoints, err := d.listBreakpoints(ctx)
+	if err != nil {
+		log.Printf("保存斷點失敗，將沿用上一次保存的斷點: %v", err)
+		return
+	}
+
+	d.mu.Lock()
+	d.breakpoints = breakpoints
+	d.mu.Unlock()
+	log.Printf("已保存 %d 個斷點", len(breakpoints))
+}
+
+func (d *debuggerMonitor) listBreakpoints(ctx context.Context) ([]delve.Breakpoint, error) {
+	client, err := delve.Dial(ctx, d.addr)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	state, err := client.State(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if state.Running {
+		if err := client.Halt(ctx); err != nil {
+			return nil, fmt.Errorf("暫停目標程式失敗: %w", err)
+		}
+	}
+	return client.ListBreakpoints(ctx)
+}
+
+// restore 在新的 Delve 上重新建立保存的斷點
+// 單一斷點建立失敗（例如程式碼已修改導致行號無效）只輸出日誌
+func (d *debuggerMonitor) restore(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, breakpointCallTimeout)
+	defer cancel()
+
+	client, err := delve.Dial(ctx, d.addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	d.mu.Lock()
+	breakpoints := d.breakpoints
+	d.mu.Unlock()
+
+	restored := 0
+	for _, bp := range breakpoints {
+		if _, err := client.CreateBreakpoint(ctx, bp); err != nil {
+			log.Printf("恢復斷點 %s 失敗: %v", bp.Location(), err)
+			continue
+		}
+		restored++
+	}
+	if len(breakpoints) > 0 {
+		log.Printf("已恢復 %d/%d 個斷點", restored, len(breakpoints))
+	}
+	return nil
+}
+
+// checkContainer 開發容器已停止時結束探測，避免等待到逾時
+func (d *debuggerMonitor) checkContainer(ctx context.Context) error {
+	running, err := d.dockerMgr.CheckContainerRunning(ctx, d.rc.GetDevContainerName())
+	if err != nil || running {
+		return nil
+	}
+	return errors.New("開發容器已停止，請查看容器日誌（例如缺少動態庫或 dlv 無法執行）")
+}
+
+func (d *debuggerMonitor) setStatus(status delve.Status) {
+	if d.update != nil {
+		d.update(status)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+var (
+	dryRun        = flag.Bool("dry-run", false, "乾跑模式：只輸出將執行的變更操作，不修改主機")
+	dryRunFixture = flag.String("dry-run-fixture", "", "乾跑時從 fixture 檔案回答唯讀查詢，不連接主機")
+	dryRunCapture = flag.String("dry-run-capture", "", "乾跑結束後將唯讀查詢結果寫入 fixture 檔案")
+)
+
+// newDryRunExecutor 建立乾跑用的 Executor
+// 指定 fixture 時完全離線；否則唯讀查詢會連接真實主機執行
+func newDryRunExecutor(rc *config.RuntimeConfig) (*executor.RecordingExecutor, error) {
+	// Engine API 後端直接透過 socket 發送變更請求，乾跑時無法攔截，改用 CLI 後端
+	if rc.Host.DockerBackend != config.DockerBackendCLI {
+		log.Printf("乾跑模式改用 %s 後端（原為 %s）", config.DockerBackendCLI, rc.Host.DockerBackend)
+		rc.Host.DockerBackend = config.DockerBackendCLI
+	}
+
+	if *dryRunFixture != "" {
+		loaded, err := executor.LoadFixture(*dryRunFixture)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("乾跑模式使用 fixture: %s", *dryRunFixture)
+		return executor.NewRecordingExecutor(nil, loaded, rc), nil
+	}
+
+	inner, err := executor.NewExecutor(rc)
+	if err != nil {
+		return nil, err
+	}
+	return executor.NewRecordingExecutor(inner, nil, rc), nil
+}
+
+// finishDryRun 輸出執行計畫並依需要保存唯讀查詢結果
+func finishDryRun(recorder *executor.RecordingExecutor) {
+	recorder.WritePlan(os.Stdout)
+
+	if *dryRunCapture != "" {
+		if err := recorder.CapturedFixture().Save(*dryRunCapture); err != nil {
+			log.Printf("保存 fixture 失敗: %v", err)
+		} else {
+			log.Printf("唯讀查詢結果已保存到: %s", *dryRunCapture)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+	"github.com/laysdragon/go-docker-dev-swap/internal/proxy"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
+	"github.com/laysdragon/go-docker-dev-swap/internal/tui"
+)
+
+// forwardStatusInterval 轉發統計（TUI 與狀態檔）的刷新間隔
+const forwardStatusInterval = time.Second
+
+// activeForward 已建立的端口轉發
+type activeForward struct {
+	name      string
+	localPort int
+	target    string
+	reverse   bool
+	tunnel    executor.TunnelCloser
+}
+
+// statsProvider 提供連線統計的轉發（tunnel 與代理）
+type statsProvider interface {
+	Stats() executor.TunnelStats
+	SetLogConnections(enabled bool)
+}
+
+// startProxy 啟動經由開發容器網路連線的 SOCKS5 / HTTP 代理
+func startProxy(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfig) (*activeForward, error) {
+	local := executor.LocalPort{Port: rc.Component.Proxy.Port, Policy: rc.Component.Proxy.PortPolicy}
+	listener, err := executor.ListenLocal(local)
+	if err != nil {
+		return nil, fmt.Errorf("啟動代理失敗: %w", err)
+	}
+
+	resolver := dockerMgr.NewServiceResolver(rc.GetDevContainerName())
+	server := proxy.New(ctx, listener, func(ctx context.Context, addr string) (net.Conn, error) {
+		return dockerMgr.DialNetwork(ctx, resolver, addr)
+	})
+	server.SetLogConnections(rc.Host.LogTunnelConnections)
+
+	logAllocatedPort("代理", local, server.ListenPort())
+	log.Printf("代理已啟動: socks5h://localhost:%d 與 http://localhost:%d", server.ListenPort(), server.ListenPort())
+	return &activeForward{
+		name:      "proxy",
+		localPort: server.ListenPort(),
+		target:    "compose 網路",
+		tunnel:    server,
+	}, nil
+}
+
+// startForwards 為 component 的 forwards 建立 tunnel，任何一個失敗時關閉已建立的 tunnel
+func startForwards(ctx context.Context, exec executor.Executor, dockerMgr *docker.Manager, rc *config.RuntimeConfig, original *docker.ContainerConfig) ([]*activeForward, error) {
+	var forwards []*activeForward
+	for _, fwd := range rc.Component.Forwards {
+		target := dockerMgr.PortTarget(original, fwd.ContainerPort, fwd.Protocol)
+		local := executor.LocalPort{Port: fwd.LocalPort, Policy: fwd.PortPolicy}
+		tunnel, err := exec.CreateTunnel(ctx, local, target)
+		if err != nil {
+			closeForwards(forwards)
+			return nil, fmt.Errorf("建立轉發 %s 失敗: %w", fwd.Name, err)
+		}
+		logAllocatedPort(fwd.Name, local, tunnel.ListenPort())
+		log.Printf("轉發 %s: localhost:%d → 容器端口 %d (%s)", fwd.Name, tunnel.ListenPort(), fwd.ContainerPort, target.Addr)
+		forwards = append(forwards, &activeForward{
+			name:      fwd.Name,
+			localPort: tunnel.ListenPort(),
+			target:    target.Addr,
+			tunnel:    tunnel,
+		})
+	}
+	return forwards, nil
+}
+
+// startReverseForwards 為 component 的 reverse_forwards 建立遠端監聽，任何一個失敗時關閉已建立的監聽
+// 注入容器且未設定 remote_bind 時監聽在 bridge 網路的 gateway（容器內 host-gateway 的地址），不對外開放
+func startReverseForwards(ctx context.Context, exec executor.Executor, dockerMgr *docker.Manager, rc *config.RuntimeConfig) ([]*activeForward, error) {
+	var (
+		forwards []*activeForward
+		gateway  string
+	)
+	for _, rev := range rc.Component.ReverseForwards {
+		bind := rev.RemoteBind
+		if bind == "" && exec.IsRemote() {
+			if gateway == "" {
+				var err error
+				if gateway, err = dockerMgr.BridgeGateway(ctx); err != nil {
+					closeForwards(forwards)
+					return nil, fmt.Errorf("反向轉發 %s 未設定 remote_bind: %w", rev.Name, err)
+				}
+			}
+			bind = gateway
+		}
+		remoteAddr := net.JoinHostPort(bind, strconv.Itoa(rev.RemotePort))
+		localAddr := net.JoinHostPort(rev.LocalHost, strconv.Itoa(rev.LocalPort))
+		tunnel, err := exec.CreateReverseTunnel(ctx, remoteAddr, localAddr)
+		if err != nil {
+			closeForwards(forwards)
+			return nil, fmt.Errorf("建立反向轉發 %s 失敗: %w", rev.Name, err)
+		}
+		if rev.Injected() {
+			log.Printf("反向轉發 %s: 容器內 %s → 本地 %s", rev.Name, rev.ContainerHost(), localAddr)
+		} else {
+			log.Printf("反向轉發 %s: 遠端 %s → 本地 %s", rev.Name, remoteAddr, localAddr)
+		}
+		forwards = append(forwards, &activeForward{
+			name:      rev.Name,
+			localPort: rev.LocalPort,
+			target:    remoteAddr,
+			reverse:   true,
+			tunnel:    tunnel,
+		})
+	}
+	return forwards, nil
+}
+
+// logAllocatedPort 實際端口與設定不同時提示，方便對照 IDE 設定
+func logAllocatedPort(name string, requested executor.LocalPort, actual int) {
+	if actual != requested.Port {
+		log.Printf("%s 的本地端口 %d 無法使用或設定為自動分配，改用端口 %d (%s)", name, requested.Port, actual, requested.Policy)
+	}
+}
+
+// closeForwards 關閉所有端口轉發
+func closeForwards(forwards []*activeForward) {
+	for _, fwd := range forwards {
+		fwd.tunnel.Close()
+	}
+}
+
+// forwardStates 返回寫入狀態檔的轉發資訊
+func forwardStates(forwards []*activeForward) []state.Forward {
+	states := make([]state.Forward, 0, len(forwards))
+	for _, fwd := range forwards {
+		st := state.Forward{
+			Name:      fwd.name,
+			LocalPort: fwd.localPort,
+			Target:    fwd.target,
+			Reverse:   fwd.reverse,
+		}
+		if provider, ok := fwd.tunnel.(statsProvider); ok {
+			stats := provider.Stats()
+			st.Active = stats.Active
+			st.Total = stats.Total
+			st.BytesIn = stats.BytesIn
+			st.BytesOut = stats.BytesOut
+			st.DialErrors = stats.DialErrors
+			st.LastError = stats.LastError
+			if !stats.LastErrorAt.IsZero() {
+				st.LastErrorAt = &stats.LastErrorAt
+			}
+		} else {
+			st.Direct = true
+		}
+		states = append(states, st)
+	}
+	return states
+}
+
+// sessionState 目前 session 的狀態檔
+type sessionState struct {
+	path string
+
+	mu    sync.Mutex
+	state *state.State
+}
+
+// newSessionState 將目前 session 的端口資訊寫入狀態檔
+// version 為目前部署的執行檔版本，未記錄版本時為 nil
+func newSessionState(rc *config.RuntimeConfig, devContainer string, forwards []*activeForward, version *state.Version) (*sessionState, error) {
+	path, err := state.Path(rc.ComponentKey, rc.HostKey)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &sessionState{
+		path: path,
+		state: &state.State{
+			PID:          os.Getpid(),
+			Component:    rc.ComponentKey,
+			Host:         rc.HostKey,
+			Project:      rc.ProjectKey,
+			Mode:         rc.Mode,
+			DevContainer: devContainer,
+			StartedAt:    now,
+			UpdatedAt:    now,
+			Version:      version,
+			Forwards:     forwardStates(forwards),
+		},
+	}
+	if err := state.Write(path, session.state); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// refresh 以最新的轉發統計更新狀態檔
+func (s *sessionState) refresh(forwards []*activeForward) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.UpdatedAt = time.Now()
+	s.state.Forwards = forwardStates(forwards)
+	return state.Write(s.path, s.state)
+}
+
+// setVersion 記錄重新部署或回滾後目前的執行檔版本
+func (s *sessionState) setVersion(version *state.Version) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Version = version
+	return state.Write(s.path, s.state)
+}
+
+// remove 刪除狀態檔
+func (s *sessionState) remove() {
+	if err := state.Remove(s.path); err != nil {
+		log.Printf("%v", err)
+	}
+}
+
+// forwardStatuses 返回端口轉發目前的連線狀態
+func forwardStatuses(forwards []*activeForward) []tui.ForwardStatus {
+	statuses := make([]tui.ForwardStatus, 0, len(forwards))
+	for _, st := range forwardStates(forwards) {
+		statuses = append(statuses, tui.ForwardStatus{
+			Name:        st.Name,
+			LocalPort:   st.LocalPort,
+			Target:      st.Target,
+			Active:      st.Active,
+			Total:       st.Total,
+			BytesIn:     st.BytesIn,
+			BytesOut:    st.BytesOut,
+			DialErrors:  st.DialErrors,
+			LastError:   st.LastError,
+			LastErrorAt: lastErrorAt(st),
+			Direct:      st.Direct,
+			Reverse:     st.Reverse,
+		})
+	}
+	return statuses
+}
+
+// lastErrorAt 返回最近一次連線失敗的時間
+func lastErrorAt(st state.Forward) time.Time {
+	if st.LastErrorAt == nil {
+		return time.Time{}
+	}
+	return *st.LastErrorAt
+}
+
+// monitorForwards 定期將轉發統計推送到 TUI 並更新狀態檔，直到 ctx 結束
+// session 或 update 為 nil 時略過對應的輸出
+func monitorForwards(ctx context.Context, forwards []*activeForward, session *sessionState, update func([]tui.ForwardStatus)) {
+	report := func() {
+		if update != nil {
+			update(forwardStatuses(forwards))
+		}
+		if session != nil {
+			if err := session.refresh(forwards); err != nil {
+				log.Printf("更新狀態檔失敗: %v", err)
+			}
+		}
+	}
+	report()
+
+	ticker := time.NewTicker(forwardStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report()
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
+	"github.com/laysdragon/go-docker-dev-swap/internal/tui"
+)
+
+// historyManifest 版本紀錄檔名，位於 GetRemoteHistoryDir() 下
+const historyManifest = "history.json"
+
+// deployHistory 在執行主機上保留最近部署的 N 個執行檔，供回滾到先前的版本
+// 執行檔以內容 hash 命名複製到 GetRemoteHistoryDir()，版本資訊寫入同目錄的 history.json，之後的 session 也能回滾
+type deployHistory struct {
+	exec  executor.Executor
+	rc    *config.RuntimeConfig
+	dir   string
+	limit int
+
+	mu       sync.Mutex
+	versions []state.Version // 依部署時間排序，最新的在前
+	current  string          // 目前部署版本的 hash
+	ready    bool            // 遠端目錄是否已建立
+}
+
+// newDeployHistory 建立版本紀錄並讀取主機上既有的紀錄，讀取失敗時從空白開始
+func newDeployHistory(ctx context.Context, exec executor.Executor, rc *config.RuntimeConfig) *deployHistory {
+	h := &deployHistory{
+		exec:  exec,
+		rc:    rc,
+		dir:   rc.GetRemoteHistoryDir(),
+		limit: *rc.Component.History,
+	}
+
+	output, err := exec.Execute(executor.WithReadOnly(ctx), fmt.Sprintf("cat -- %s 2>/dev/null || true", shellQuote(path.Join(h.dir, historyManifest))))
+	if err != nil {
+		log.Printf("讀取版本紀錄失敗: %v", err)
+		return h
+	}
+	if strings.TrimSpace(output) == "" {
+		return h
+	}
+	if err := json.Unmarshal([]byte(output), &h.versions); err != nil {
+		log.Printf("解析版本紀錄失敗，重新開始記錄: %v", err)
+		h.versions = nil
+	}
+	// 超過保留數量的舊版本在下次部署時刪除
+	return h
+}
+
+// Record 將剛部署到 GetRemoteBinaryPath() 的執行檔加入紀錄，並刪除超過保留數量的舊版本
+func (h *deployHistory) Record(ctx context.Context, v *state.Version) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.ready {
+		if _, err := h.exec.Execute(ctx, "mkdir -p -- "+shellQuote(h.dir)); err != nil {
+			return fmt.Errorf("建立版本紀錄目錄失敗: %w", err)
+		}
+		h.ready = true
+	}
+
+	// 相同內容的執行檔只保存一份
+	dst := shellQuote(h.file(v.Hash))
+	copyCmd := fmt.Sprintf("test -f %s || cp -f -- %s %s", dst, shellQuote(h.rc.GetRemoteBinaryPath()), dst)
+	if _, err := h.exec.Execute(ctx, copyCmd); err != nil {
+		return fmt.Errorf("保存執行檔失敗: %w", err)
+	}
+
+	entry := *v
+	entry.DeployedAt = time.Now()
+	return h.promote(ctx, entry)
+}
+
+// Rollback 將紀錄中的版本重新部署到 GetRemoteBinaryPath()，呼叫端需自行重新啟動程式
+func (h *deployHistory) Rollback(ctx context.Context, hash string) (*state.Version, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := h.index(hash)
+	if i < 0 {
+		return nil, fmt.Errorf("找不到版本 %s", hash)
+	}
+	entry := h.versions[i]
+
+	// 先複製到暫存檔再 rename，運行中的程式仍使用舊檔案
+	binary := h.rc.GetRemoteBinaryPath()
+	tmp := binary + ".rollback"
+	restore := fmt.Sprintf("cp -f -- %s %s && mv -f -- %s %s", shellQuote(h.file(hash)), shellQuote(tmp), shellQuote(tmp), shellQuote(binary))
+	if _, err := h.exec.Execute(ctx, restore); err != nil {
+		return nil, fmt.Errorf("還原執行檔失敗: %w", err)
+	}
+
+	entry.DeployedAt = time.Now()
+	if err := h.promote(ctx, entry); err != nil {
+		return &entry, err
+	}
+	return &entry, nil
+}
+
+// Versions 返回紀錄中的版本，最新部署的在前
+func (h *deployHistory) Versions() []state.Version {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]state.Version(nil), h.versions...)
+}
+
+// Current 返回目前部署的版本，尚未部署時返回 nil
+func (h *deployHistory) Current() *state.Version {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := h.index(h.current); i >= 0 {
+		v := h.versions[i]
+		return &v
+	}
+	return nil
+}
+
+// CurrentHash 返回目前部署版本的 hash
+func (h *deployHistory) CurrentHash() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.current
+}
+
+// promote 將版本移到紀錄最前面並設為目前版本，刪除超過保留數量的舊版本後寫回紀錄檔；呼叫端需持有 mu
+func (h *deployHistory) promote(ctx context.Context, entry state.Version) error {
+	if i := h.index(entry.Hash); i >= 0 {
+		h.versions = append(h.versions[:i], h.versions[i+1:]...)
+	}
+	h.versions = append([]state.Version{entry}, h.versions...)
+	h.current = entry.Hash
+
+	if len(h.versions) > h.limit {
+		pruned := h.versions[h.limit:]
+		h.versions = h.versions[:h.limit]
+		files := make([]string, 0, len(pruned))
+		for _, v := range pruned {
+			files = append(files, shellQuote(h.file(v.Hash)))
+		}
+		if _, err := h.exec.Execute(ctx, "rm -f -- "+strings.Join(files, " ")); err != nil {
+			log.Printf("刪除舊版本失敗: %v", err)
+		}
+	}
+	return h.writeManifest(ctx)
+}
+
+// writeManifest 將版本紀錄寫到主機上的 history.json；呼叫端需持有 mu
+func (h *deployHistory) writeManifest(ctx context.Context) error {
+	data, err := json.MarshalIndent(h.versions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("編碼版本紀錄失敗: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "docker-dev-swap-history-*.json")
+	if err != nil {
+		return fmt.Errorf("建立暫存檔失敗: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("寫入暫存檔失敗: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("寫入暫存檔失敗: %w", err)
+	}
+
+	if err := h.exec.UploadFileMode(ctx, tmp.Name(), path.Join(h.dir, historyManifest), 0644); err != nil {
+		return fmt.Errorf("上傳版本紀錄失敗: %w", err)
+	}
+	return nil
+}
+
+// index 返回 hash 在紀錄中的位置，不存在時返回 -1；呼叫端需持有 mu
+func (h *deployHistory) index(hash string) int {
+	for i, v := range h.versions {
+		if v.Hash == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// file 返回版本在主機上保存的執行檔路徑
+func (h *deployHistory) file(hash string) string {
+	return path.Join(h.dir, hash)
+}
+
+// snapshotBinary 將本地執行檔複製到暫存檔並讀取版本資訊，以檔案修改時間作為編譯時間
+// 上傳暫存檔而非 local_binary，即使上傳期間重新編譯，記錄的版本也與上傳的內容一致；呼叫端需刪除暫存檔
+// 執行檔沒有 buildinfo（例如非 Go 編譯）時只記錄 hash
+func snapshotBinary(binaryPath string) (string, *state.Version, error) {
+	src, err := os.Open(binaryPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("開啟執行檔失敗: %w", err)
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return "", nil, fmt.Errorf("讀取執行檔資訊失敗: %w", err)
+	}
+
+	tmp, err := os.CreateTemp("", "docker-dev-swap-binary-*")
+	if err != nil {
+		return "", nil, fmt.Errorf("建立暫存檔失敗: %w", err)
+	}
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), src); err != nil {
+		os.Remove(tmp.Name())
+		return "", nil, fmt.Errorf("複製執行檔失敗: %w", err)
+	}
+
+	v := &state.Version{Hash: hex.EncodeToString(hash.Sum(nil)), BuildTime: info.ModTime()}
+	if bi, err := buildinfo.Read(tmp); err == nil {
+		applyBuildInfo(v, bi)
+	}
+	return tmp.Name(), v, nil
+}
+
+// uploadLocalBinary 上傳 local_binary；h 不為 nil 時上傳快照並返回其版本，供上傳後以 Record 記錄
+func uploadLocalBinary(ctx context.Context, exec executor.Executor, rc *config.RuntimeConfig, h *deployHistory) (*state.Version, error) {
+	if h == nil {
+		return nil, exec.UploadFile(ctx, rc.Component.LocalBinary, rc.GetRemoteBinaryPath())
+	}
+
+	snapshot, v, err := snapshotBinary(rc.Component.LocalBinary)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(snapshot)
+	if err := exec.UploadFile(ctx, snapshot, rc.GetRemoteBinaryPath()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// applyBuildInfo 從 buildinfo 取出 Go 版本與 VCS 資訊
+func applyBuildInfo(v *state.Version, info *debug.BuildInfo) {
+	v.GoVersion = info.GoVersion
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			v.Revision = setting.Value
+		case "vcs.modified":
+			v.Modified = setting.Value == "true"
+		}
+	}
+}
+
+// RecordDeployed 將剛部署的執行檔加入紀錄
+// remote 不為 nil 時執行檔在執行主機上編譯，從主機讀取版本；否則使用 uploadLocalBinary 返回的 local
+func (h *deployHistory) RecordDeployed(ctx context.Context, local *state.Version, remote *remoteBuilder) (*state.Version, error) {
+	v := local
+	if remote != nil {
+		var err error
+		if v, err = remote.Version(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if v == nil {
+		return nil, fmt.Errorf("沒有可記錄的版本資訊")
+	}
+	if err := h.Record(ctx, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// versionInfos 將版本紀錄轉換為 TUI 顯示用的格式
+func versionInfos(h *deployHistory) []tui.VersionInfo {
+	current := h.CurrentHash()
+	versions := h.Versions()
+	infos := make([]tui.VersionInfo, 0, len(versions))
+	for _, v := range versions {
+		infos = append(infos, tui.VersionInfo{
+			Hash:       v.Hash,
+			ShortHash:  v.ShortHash(),
+			Revision:   v.ShortRevision(),
+			GoVersion:  v.GoVersion,
+			BuildTime:  v.BuildTime,
+			DeployedAt: v.DeployedAt,
+			Current:    v.Hash == current,
+		})
+	}
+	return infos
+}
+
+// describeVersion 返回日誌用的版本描述
+func describeVersion(v *state.Version) string {
+	if rev := v.ShortRevision(); rev != "" {
+		return fmt.Sprintf("%s（revision %s）", v.ShortHash(), rev)
+	}
+	return v.ShortHash()
+}
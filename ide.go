@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/ide"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
+)
+
+var ideEditors = flag.String("ide", "", "啟動後產生 / 更新 IDE 設定：vscode、goland 或 all，以逗號分隔")
+
+// runIDECommand 實作 ide 子命令：產生或更新連接目前組合的 IDE 設定
+// 有執行中的 session 時使用狀態檔中實際的 debugger 端口
+func runIDECommand(args []string) {
+	editors, err := parseEditors(strings.Join(args, ","))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	rc := loadRuntimeConfig()
+	port, running := sessionDebuggerPort(rc)
+	if !running && rc.Component.DebuggerPortPolicy != config.PortPolicyFixed {
+		log.Printf("debugger_port_policy 為 %s，實際端口在啟動時才決定，可在啟動時使用 --ide 自動更新", rc.Component.DebuggerPortPolicy)
+	}
+
+	if err := writeIDEConfigs(rc, port, editors); err != nil {
+		log.Fatalf("產生 IDE 設定失敗: %v", err)
+	}
+}
+
+// parseEditors 解析以逗號分隔的編輯器清單，空字串或 all 表示全部
+func parseEditors(value string) ([]string, error) {
+	if value == "" || value == "all" {
+		return ide.Editors, nil
+	}
+	var editors []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return ide.Editors, nil
+		}
+		if !slices.Contains(ide.Editors, name) {
+			return nil, fmt.Errorf("不支援的 IDE: %s（可用: %s）", name, strings.Join(ide.Editors, ", "))
+		}
+		editors = append(editors, name)
+	}
+	return editors, nil
+}
+
+// sessionDebuggerPort 返回 IDE 應連接的本地 debugger 端口
+// 有執行中的 session 時讀取狀態檔，否則依配置推算；第二個返回值表示是否來自執行中的 session
+func sessionDebuggerPort(rc *config.RuntimeConfig) (int, bool) {
+	if path, err := state.Path(rc.ComponentKey, rc.HostKey); err == nil {
+		if st, err := state.Load(path); err == nil && st.Alive() {
+			for _, fwd := range st.Forwards {
+				if fwd.Name == "debugger" {
+					return fwd.LocalPort, true
+				}
+			}
+		}
+	}
+
+	// 本地模式且未使用 container tunnel 時直接連接發布在主機上的 dlv 端口
+	if rc.Mode == "local" && rc.Host.TunnelTarget != config.TunnelTargetContainer {
+		return rc.Component.DlvConfig.Port, false
+	}
+	return rc.Component.DebuggerPort, false
+}
+
+// writeIDEConfigs 依本地執行檔的建置路徑產生 substitutePath，並寫入各編輯器的設定
+func writeIDEConfigs(rc *config.RuntimeConfig, port int, editors []string) error {
+	workspace := "."
+	var (
+		substitutions []ide.Substitution
+		source        *ide.SourceInfo
+		err           error
+	)
+	if build := rc.Component.Build; build != nil && build.Strategy == config.BuildStrategyRemote {
+		// 遠端編譯不會在本機產生執行檔，原始碼路徑固定對應到建置容器的掛載位置
+		source, err = remoteSourceInfo(build)
+	} else if source, err = ide.ReadSourceInfo(rc.Component.LocalBinary, "."); err != nil {
+		// 從執行檔所在目錄再找一次，例如在其他目錄執行本工具
+		source, err = ide.ReadSourceInfo(rc.Component.LocalBinary, filepath.Dir(rc.Component.LocalBinary))
+	}
+	if err != nil {
+		log.Printf("無法取得原始碼路徑，產生的設定不含 substitutePath: %v", err)
+	} else {
+		workspace = source.Workspace
+		substitutions = source.Substitutions()
+		if len(substitutions) > 0 {
+			log.Printf("原始碼路徑對應: %s → %s", source.Workspace, source.BuildRoot)
+		}
+	}
+
+	target := ide.Target{
+		Name:          fmt.Sprintf("docker-dev-swap %s@%s", rc.ComponentKey, rc.HostKey),
+		Host:          "localhost",
+		Port:          port,
+		Mode:          rc.Component.DlvConfig.Mode,
+		Program:       rc.GetStagedBinaryPath(),
+		Substitutions: substitutions,
+	}
+	for _, editor := range editors {
+		path, ok, err := ide.Write(editor, workspace, target)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			log.Printf("%s 不支援 %s 模式，略過", editor, target.Mode)
+			continue
+		}
+		log.Printf("已更新 %s 設定: %s（localhost:%d）", editor, path, port)
+	}
+	return nil
+}
@@ -2,7 +2,13 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +17,32 @@ const (
 	ProjectTypeCompose        = "compose"
 	ProjectTypeContainer      = "container"
 	defaultContainerProjectID = "docker-container"
+
+	DockerBackendCLI = "cli" // 透過 docker CLI 操作容器
+	DockerBackendAPI = "api" // 直接呼叫 Docker Engine API
+
+	TunnelTargetHost      = "host"      // tunnel 連接開發容器發布在主機上的端口
+	TunnelTargetContainer = "container" // tunnel 直接連接開發容器 IP，不在主機上發布端口
+
+	ReverseForwardHost = "host.docker.internal" // 開發容器連回反向轉發時使用的預設主機名稱
+
+	ContainerStageDir = "/.dev-swap" // 遠端工作目錄在開發容器內的唯讀掛載位置
+
+	PortPolicyFixed     = "fixed"     // 只使用指定的本地端口，被佔用時失敗
+	PortPolicyNextFree  = "next_free" // 指定端口被佔用時依序嘗試下一個可用端口
+	PortPolicyEphemeral = "ephemeral" // 由系統分配任意可用端口
+
+	DlvModeAPI = "api" // dlv exec --headless，JSON-RPC API v2（工具可管理斷點與繼續執行）
+	DlvModeDAP = "dap" // dlv dap，供 VS Code dlv-dap、Neovim、Helix 等使用 DAP 的編輯器連接
+
+	ComponentKindBinary = "binary" // 部署本地編譯的 Go 執行檔（預設）
+	ComponentKindSync   = "sync"   // 同步本地原始碼目錄，用於 Node、Python 等直譯式服務
+
+	SyncOnChangeRestart = "restart" // 同步後由 supervisor 在容器內重新啟動程式，失敗時才重啟開發容器
+	SyncOnChangeSignal  = "signal"  // 同步後向容器內的程序送出信號
+
+	BuildStrategyLocal  = "local"  // 在本機執行編譯命令
+	BuildStrategyRemote = "remote" // 同步原始碼到執行主機，在符合目標平台的建置容器中編譯
 )
 
 // Config 主配置結構，支援多組組件、主機和專案配置
@@ -27,15 +59,24 @@ type Config struct {
 
 // Component 本地組件配置
 type Component struct {
-	Name                string     `mapstructure:"name"`                  // 組件名稱（顯示用）
-	LocalBinary         string     `mapstructure:"local_binary"`          // 本地編譯的執行檔路徑
-	TargetService       string     `mapstructure:"target_service"`        // 目標服務名稱
-	ContainerBinaryPath string     `mapstructure:"container_binary_path"` // 容器內的執行檔路徑
-	DebuggerPort        int        `mapstructure:"debugger_port"`         // Debugger 端口
-	ExtraPorts          []int      `mapstructure:"extra_ports"`           // 額外需要暴露的端口
-	DlvConfig           *DlvConfig `mapstructure:"dlv_config"`            // Delve 配置（nil 表示使用全局預設）
-	InitialScripts      *string    `mapstructure:"initial_scripts"`       // 容器啟動前執行的初始化腳本（nil 表示使用全局預設）
-	LogFile             *string    `mapstructure:"log_file"`              // 日誌文件路徑（nil 表示使用全局預設）
+	Name                string           `mapstructure:"name"`                  // 組件名稱（顯示用）
+	Kind                string           `mapstructure:"kind"`                  // 組件類型：binary 或 sync
+	LocalBinary         string           `mapstructure:"local_binary"`          // 本地編譯的執行檔路徑（binary 類型需要）
+	Sync                *SyncConfig      `mapstructure:"sync"`                  // 目錄同步配置（sync 類型需要）
+	TargetService       string           `mapstructure:"target_service"`        // 目標服務名稱
+	ContainerBinaryPath string           `mapstructure:"container_binary_path"` // 容器內的執行檔路徑
+	DebuggerPort        int              `mapstructure:"debugger_port"`         // Debugger 端口
+	DebuggerPortPolicy  string           `mapstructure:"debugger_port_policy"`  // Debugger 本地端口分配策略
+	ExtraPorts          []int            `mapstructure:"extra_ports"`           // 額外需要暴露的端口
+	Forwards            []Forward        `mapstructure:"forwards"`              // 透過 tunnel 轉發到本地的容器端口
+	ReverseForwards     []ReverseForward `mapstructure:"reverse_forwards"`      // 讓開發容器連回本地服務的反向轉發
+	Proxy               ProxyConfig      `mapstructure:"proxy"`                 // 連入開發容器網路的本地代理
+	Build               *BuildConfig     `mapstructure:"build"`                 // 內建編譯步驟（nil 表示由使用者自行編譯 local_binary）
+	Artifacts           []Artifact       `mapstructure:"artifacts"`             // 與執行檔一同部署的檔案或目錄
+	History             *int             `mapstructure:"history"`               // 在主機上保留最近部署的執行檔數量，供回滾（nil 表示使用預設值，0 表示停用）
+	DlvConfig           *DlvConfig       `mapstructure:"dlv_config"`            // Delve 配置（nil 表示使用全局預設）
+	InitialScripts      *string          `mapstructure:"initial_scripts"`       // 容器啟動前執行的初始化腳本（nil 表示使用全局預設）
+	LogFile             *string          `mapstructure:"log_file"`              // 日誌文件路徑（nil 表示使用全局預設）
 }
 
 // Host 主機配置（包含 mode、sudo、docker、projects）
@@ -58,6 +99,15 @@ type Host struct {
 	// Docker 命令配置
 	DockerCommand        string `mapstructure:"docker_command"`         // docker 命令
 	DockerComposeCommand string `mapstructure:"docker_compose_command"` // docker-compose 命令
+	DockerBackend        string `mapstructure:"docker_backend"`         // 容器操作後端："cli" 或 "api"
+	DockerSocket         string `mapstructure:"docker_socket"`          // Docker Engine socket 路徑（api 後端使用）
+
+	// Tunnel 配置
+	TunnelTarget         string `mapstructure:"tunnel_target"`          // debugger 與 forwards 的連接方式："host" 或 "container"
+	LogTunnelConnections bool   `mapstructure:"log_tunnel_connections"` // 是否在工作日誌輸出每個 tunnel 連線的建立與關閉
+
+	// 操作逾時配置
+	Timeouts Timeouts `mapstructure:"timeouts"`
 
 	// 專案列表
 	Projects map[string]Project `mapstructure:"projects"` // 該主機上的專案配置
@@ -70,12 +120,104 @@ type Project struct {
 	ComposeDir string `mapstructure:"compose_dir"` // docker-compose.yml 所在目錄（compose 類型需要）
 }
 
+// Timeouts 操作逾時配置（未設定時使用預設值，負值表示不限制）
+type Timeouts struct {
+	Command time.Duration `mapstructure:"command"` // 單一指令執行逾時
+	Upload  time.Duration `mapstructure:"upload"`  // 檔案上傳 / 腳本建立逾時
+	Cleanup time.Duration `mapstructure:"cleanup"` // 退出時清理流程的總逾時
+}
+
+// Forward 容器端口轉發配置（本地端口 → 容器端口）
+type Forward struct {
+	Name          string `mapstructure:"name"`           // 轉發名稱（顯示用）
+	LocalPort     int    `mapstructure:"local_port"`     // 本地監聽端口（預設與 container_port 相同）
+	ContainerPort int    `mapstructure:"container_port"` // 容器內的端口
+	Protocol      string `mapstructure:"protocol"`       // 協定，目前僅支援 tcp
+	PortPolicy    string `mapstructure:"port_policy"`    // 本地端口分配策略
+}
+
+// ReverseForward 反向端口轉發配置（遠端主機端口 → 本地服務）
+type ReverseForward struct {
+	Name       string `mapstructure:"name"`        // 轉發名稱（顯示用）
+	RemotePort int    `mapstructure:"remote_port"` // 遠端主機上監聽的端口（預設與 local_port 相同）
+	RemoteBind string `mapstructure:"remote_bind"` // 遠端主機上監聽的地址（注入容器時預設為 bridge 網路的 gateway，否則 127.0.0.1；0.0.0.0 需明確設定）
+	LocalPort  int    `mapstructure:"local_port"`  // 本地服務端口
+	LocalHost  string `mapstructure:"local_host"`  // 本地服務地址（預設 localhost）
+	HostAlias  string `mapstructure:"host_alias"`  // 注入開發容器的主機名稱，指向 host gateway
+	Env        string `mapstructure:"env"`         // 注入開發容器的環境變數名稱，值為 <主機名稱>:<端口>
+}
+
+// ProxyConfig 本地 SOCKS5 / HTTP 代理配置，經由開發容器所在的網路連線
+type ProxyConfig struct {
+	Enabled    bool   `mapstructure:"enabled"`     // 是否啟動代理
+	Port       int    `mapstructure:"port"`        // 本地監聽端口
+	PortPolicy string `mapstructure:"port_policy"` // 本地端口分配策略
+}
+
+// Artifact 與執行檔一同部署的檔案或目錄，例如設定檔、模板、migration、plugin 或其他執行檔
+// 上傳到遠端工作目錄後掛載到容器內；目錄以增量方式同步，容器內可即時看到變更
+type Artifact struct {
+	Name          string `mapstructure:"name"`           // 名稱（顯示用，也作為遠端存放目錄名稱）
+	LocalPath     string `mapstructure:"local_path"`     // 本地檔案或目錄
+	ContainerPath string `mapstructure:"container_path"` // 容器內的掛載路徑
+	Mode          string `mapstructure:"mode"`           // 上傳後的檔案權限（八進位，例如 0644），預設沿用本地權限
+	Restart       *bool  `mapstructure:"restart"`        // 變更後是否重啟開發容器（nil 表示重啟）
+}
+
+// FileMode 返回上傳檔案使用的權限，未設定 mode 時使用本地檔案的權限
+func (a Artifact) FileMode(local os.FileMode) os.FileMode {
+	if a.Mode == "" {
+		return local.Perm()
+	}
+	mode, _ := strconv.ParseUint(a.Mode, 8, 32)
+	return os.FileMode(mode)
+}
+
+// RestartOnChange 判斷變更後是否需要重啟開發容器
+func (a Artifact) RestartOnChange() bool {
+	return a.Restart == nil || *a.Restart
+}
+
+// IsFile 判斷 local_path 是否為單一檔案，無法讀取時視為目錄
+func (a Artifact) IsFile() bool {
+	info, err := os.Stat(a.LocalPath)
+	return err == nil && !info.IsDir()
+}
+
+// SyncConfig kind: sync 的目錄同步配置
+// 本地目錄增量同步到遠端工作目錄後掛載到容器內，變更時重啟容器或向程序送出信號
+type SyncConfig struct {
+	LocalDir     string        `mapstructure:"local_dir"`     // 要同步的本地目錄
+	ContainerDir string        `mapstructure:"container_dir"` // 容器內的掛載路徑，也是啟動命令的工作目錄
+	Ignore       []string      `mapstructure:"ignore"`        // 不同步也不觸發重啟的 glob（相對於 local_dir，支援 **）
+	Command      string        `mapstructure:"command"`       // 啟動命令，預設沿用原始容器的命令
+	OnChange     string        `mapstructure:"on_change"`     // 同步後的動作：restart 或 signal
+	Signal       string        `mapstructure:"signal"`        // on_change 為 signal 時送出的信號
+	Debounce     time.Duration `mapstructure:"debounce"`      // 最後一次變更後等待多久才同步
+}
+
+// BuildConfig 內建編譯步驟，原始碼變更時自動編譯 local_binary，成功後才部署
+type BuildConfig struct {
+	Strategy string            `mapstructure:"strategy"` // local 或 remote
+	Image    string            `mapstructure:"image"`    // remote 使用的建置映像，預設依 go.mod 與目標 libc 選擇 golang 映像
+	Command  string            `mapstructure:"command"`  // 編譯命令（以 sh 執行），預設以 -N -l 編譯到 local_binary（remote 為 $DEV_SWAP_OUTPUT）
+	WorkDir  string            `mapstructure:"workdir"`  // 執行編譯與監控原始碼的目錄
+	Env      map[string]string `mapstructure:"env"`      // 額外的環境變數，例如 GOOS、GOARCH、CGO_ENABLED（名稱一律轉為大寫）
+	Watch    []string          `mapstructure:"watch"`    // 觸發編譯的檔案 glob（相對於 workdir，支援 **）
+	Ignore   []string          `mapstructure:"ignore"`   // 不觸發編譯的檔案 glob
+	Debounce time.Duration     `mapstructure:"debounce"` // 最後一次變更後等待多久才開始編譯
+}
+
 // DlvConfig Delve 調試器配置
 type DlvConfig struct {
 	Enabled   bool   `mapstructure:"enabled"`
 	Port      int    `mapstructure:"port"`
 	Args      string `mapstructure:"args"`
 	LocalPath string `mapstructure:"local_path"` // 本地 dlv 路徑，為空則自動搜尋
+
+	Mode            string        `mapstructure:"mode"`              // dlv 服務模式：api（JSON-RPC API v2）或 dap
+	ReadyTimeout    time.Duration `mapstructure:"ready_timeout"`     // 等待 Delve 接受連線的上限，負值表示不限制
+	WaitForDebugger bool          `mapstructure:"wait_for_debugger"` // 目標程式保持暫停，直到 debugger 連接並繼續執行
 }
 
 // RemoteHost SSH 連接配置（用於 executor）
@@ -89,6 +231,9 @@ type RemoteHost struct {
 
 // RuntimeConfig 運行時選擇的配置組合
 type RuntimeConfig struct {
+	ComponentKey         string // 選擇的 component key
+	HostKey              string // 選擇的 host key
+	ProjectKey           string // 選擇的 project key
 	Mode                 string
 	Component            Component // 使用原始 Component（已在選擇時合併全局預設值）
 	Host                 Host
@@ -105,17 +250,45 @@ var defaultValues = struct {
 	LogFile        string
 	InitialScripts string
 	DlvConfig      struct {
-		Enabled   bool
-		Port      int
-		Args      string
-		LocalPath string
+		Enabled         bool
+		Port            int
+		Args            string
+		LocalPath       string
+		Mode            string
+		ReadyTimeout    time.Duration
+		WaitForDebugger bool
 	}
 
 	// Component 預設值
 	Component struct {
 		ContainerBinaryPath string
 		DebuggerPort        int
-		//ExtraPorts          []int
+		ExtraPorts          []int
+		ForwardProtocol     string
+		ReverseLocalHost    string
+		ReverseRemoteBind   string
+		PortPolicy          string
+		ProxyPort           int
+		History             int
+	}
+
+	// Sync 預設值
+	Sync struct {
+		Ignore   []string
+		OnChange string
+		Signal   string
+		Debounce time.Duration
+	}
+
+	// Build 預設值
+	Build struct {
+		Strategy      string
+		Command       string
+		RemoteCommand string
+		WorkDir       string
+		Watch         []string
+		Ignore        []string
+		Debounce      time.Duration
 	}
 
 	// Host 預設值
@@ -128,32 +301,86 @@ var defaultValues = struct {
 		SudoPassword         string
 		DockerCommand        string
 		DockerComposeCommand string
+		DockerBackend        string
+		DockerSocket         string
+		TunnelTarget         string
+		Timeouts             Timeouts
 	}
 }{
 	// 全局預設值
 	LogFile:        "",
 	InitialScripts: "",
 	DlvConfig: struct {
-		Enabled   bool
-		Port      int
-		Args      string
-		LocalPath string
+		Enabled         bool
+		Port            int
+		Args            string
+		LocalPath       string
+		Mode            string
+		ReadyTimeout    time.Duration
+		WaitForDebugger bool
 	}{
-		Enabled:   false,
-		Port:      2345,
-		Args:      "",
-		LocalPath: "",
+		Enabled:         false,
+		Port:            2345,
+		Args:            "",
+		LocalPath:       "",
+		Mode:            DlvModeAPI,
+		ReadyTimeout:    30 * time.Second,
+		WaitForDebugger: false,
 	},
 
 	// Component 預設值
 	Component: struct {
 		ContainerBinaryPath string
 		DebuggerPort        int
-		//ExtraPorts          []int
+		ExtraPorts          []int
+		ForwardProtocol     string
+		ReverseLocalHost    string
+		ReverseRemoteBind   string
+		PortPolicy          string
+		ProxyPort           int
+		History             int
 	}{
 		ContainerBinaryPath: "/app/service",
 		DebuggerPort:        2345,
-		//ExtraPorts:          []int{},
+		ExtraPorts:          []int{},
+		ForwardProtocol:     "tcp",
+		ReverseLocalHost:    "localhost",
+		ReverseRemoteBind:   "127.0.0.1",
+		PortPolicy:          PortPolicyFixed,
+		ProxyPort:           1080,
+		History:             5,
+	},
+
+	// Sync 預設值
+	Sync: struct {
+		Ignore   []string
+		OnChange string
+		Signal   string
+		Debounce time.Duration
+	}{
+		Ignore:   []string{"**/.git/**", "**/node_modules/**", "**/__pycache__/**", "*.pyc", "**/.venv/**"},
+		OnChange: SyncOnChangeRestart,
+		Signal:   "HUP",
+		Debounce: 500 * time.Millisecond,
+	},
+
+	// Build 預設值
+	Build: struct {
+		Strategy      string
+		Command       string
+		RemoteCommand string
+		WorkDir       string
+		Watch         []string
+		Ignore        []string
+		Debounce      time.Duration
+	}{
+		Strategy:      BuildStrategyLocal,
+		Command:       `go build -gcflags="all=-N -l" -o %q .`,
+		RemoteCommand: `go build -gcflags="all=-N -l" -o "$DEV_SWAP_OUTPUT" .`,
+		WorkDir:       ".",
+		Watch:         []string{"**/*.go", "go.mod", "go.sum"},
+		Ignore:        []string{"*_test.go"},
+		Debounce:      500 * time.Millisecond,
 	},
 
 	// Host 預設值
@@ -166,6 +393,10 @@ var defaultValues = struct {
 		SudoPassword         string
 		DockerCommand        string
 		DockerComposeCommand string
+		DockerBackend        string
+		DockerSocket         string
+		TunnelTarget         string
+		Timeouts             Timeouts
 	}{
 		Mode:                 "remote",
 		Port:                 22,
@@ -175,14 +406,50 @@ var defaultValues = struct {
 		SudoPassword:         "",
 		DockerCommand:        "docker",
 		DockerComposeCommand: "docker compose",
+		DockerBackend:        DockerBackendCLI,
+		DockerSocket:         "/var/run/docker.sock",
+		TunnelTarget:         TunnelTargetHost,
+		Timeouts: Timeouts{
+			Command: 2 * time.Minute,
+			Upload:  10 * time.Minute,
+			Cleanup: time.Minute,
+		},
 	},
 }
 
+// validPortPolicy 判斷端口分配策略是否有效
+func validPortPolicy(policy string) bool {
+	switch policy {
+	case PortPolicyFixed, PortPolicyNextFree, PortPolicyEphemeral:
+		return true
+	}
+	return false
+}
+
+// Injected 判斷反向轉發是否需要注入開發容器
+func (r ReverseForward) Injected() bool {
+	return r.HostAlias != "" || r.Env != ""
+}
+
+// ContainerHost 返回開發容器內連接此反向轉發使用的主機名稱
+func (r ReverseForward) ContainerHost() string {
+	if r.HostAlias != "" {
+		return r.HostAlias
+	}
+	return ReverseForwardHost
+}
+
 // GetRemoteBinaryPath 返回完整的遠端執行檔路徑
 func (rc *RuntimeConfig) GetRemoteBinaryPath() string {
 	return fmt.Sprintf("%s/%s", rc.Host.RemoteWorkDir, rc.Host.RemoteBinaryName)
 }
 
+// GetStagedBinaryPath 返回部署的執行檔在開發容器內的路徑（位於 ContainerStageDir 目錄掛載中）
+// 執行檔上傳後換成新的檔案，經由目錄掛載在容器內立即可見，supervisor 直接執行此路徑
+func (rc *RuntimeConfig) GetStagedBinaryPath() string {
+	return path.Join(ContainerStageDir, rc.Host.RemoteBinaryName)
+}
+
 // GetRemoteDlvPath 返回完整的遠端 dlv 路徑
 func (rc *RuntimeConfig) GetRemoteDlvPath() string {
 	return fmt.Sprintf("%s/dlv", rc.Host.RemoteWorkDir)
@@ -198,6 +465,47 @@ func (rc *RuntimeConfig) GetRemoteEntryScriptPath() string {
 	return fmt.Sprintf("%s/entry.sh", rc.Host.RemoteWorkDir)
 }
 
+// GetRemoteArtifactsDir 返回所有 artifacts 在遠端的存放目錄
+func (rc *RuntimeConfig) GetRemoteArtifactsDir() string {
+	return fmt.Sprintf("%s/artifacts", rc.Host.RemoteWorkDir)
+}
+
+// GetRemoteArtifactPath 返回 artifact 在遠端的存放路徑
+func (rc *RuntimeConfig) GetRemoteArtifactPath(name string) string {
+	return fmt.Sprintf("%s/%s", rc.GetRemoteArtifactsDir(), name)
+}
+
+// GetRemoteArtifactFilePath 返回單一檔案 artifact 在遠端的存放路徑
+// 檔案放在以名稱命名的目錄中（檔名沿用 container_path），經由目錄掛載後上傳換成新檔案也能在容器內看到
+func (rc *RuntimeConfig) GetRemoteArtifactFilePath(art Artifact) string {
+	return path.Join(rc.GetRemoteArtifactPath(art.Name), path.Base(art.ContainerPath))
+}
+
+// GetStagedArtifactFilePath 返回單一檔案 artifact 在開發容器內的存放路徑，container_path 為指向此路徑的符號連結
+func (rc *RuntimeConfig) GetStagedArtifactFilePath(art Artifact) string {
+	return path.Join(ContainerStageDir, "artifacts", art.Name, path.Base(art.ContainerPath))
+}
+
+// GetRemoteSourcePath 返回 build.strategy 為 remote 時同步原始碼的遠端目錄
+func (rc *RuntimeConfig) GetRemoteSourcePath() string {
+	return fmt.Sprintf("%s/src", rc.Host.RemoteWorkDir)
+}
+
+// GetRemoteBuildDir 返回建置容器輸出執行檔的遠端目錄
+func (rc *RuntimeConfig) GetRemoteBuildDir() string {
+	return fmt.Sprintf("%s/build", rc.Host.RemoteWorkDir)
+}
+
+// GetRemoteSyncPath 返回 sync 類型組件的遠端同步目錄
+func (rc *RuntimeConfig) GetRemoteSyncPath() string {
+	return fmt.Sprintf("%s/sync", rc.Host.RemoteWorkDir)
+}
+
+// GetRemoteHistoryDir 返回保存已部署執行檔與版本紀錄的遠端目錄
+func (rc *RuntimeConfig) GetRemoteHistoryDir() string {
+	return fmt.Sprintf("%s/history/%s", rc.Host.RemoteWorkDir, rc.ComponentKey)
+}
+
 // GetDevContainerName 返回開發容器名稱
 func (rc *RuntimeConfig) GetDevContainerName() string {
 	return fmt.Sprintf("%s-dev", rc.Component.TargetService)
@@ -250,11 +558,147 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("dlv_config.port", defaultValues.DlvConfig.Port)
 	v.SetDefault("dlv_config.args", defaultValues.DlvConfig.Args)
 	v.SetDefault("dlv_config.local_path", defaultValues.DlvConfig.LocalPath)
+	v.SetDefault("dlv_config.mode", defaultValues.DlvConfig.Mode)
+	v.SetDefault("dlv_config.ready_timeout", defaultValues.DlvConfig.ReadyTimeout)
+	v.SetDefault("dlv_config.wait_for_debugger", defaultValues.DlvConfig.WaitForDebugger)
 
 	// 注意：Components、Hosts 是 map，無法在此設定預設值
 	// 它們的預設值會在 validateConfig 中針對每個項目設定
 }
 
+// validateDlvConfig 設定 dlv_config 的預設值並驗證
+func validateDlvConfig(dlv *DlvConfig) error {
+	if dlv.Mode == "" {
+		dlv.Mode = defaultValues.DlvConfig.Mode
+	}
+	if dlv.Mode != DlvModeAPI && dlv.Mode != DlvModeDAP {
+		return fmt.Errorf("mode 必須是 '%s' 或 '%s'", DlvModeAPI, DlvModeDAP)
+	}
+	return nil
+}
+
+// validateBuildConfig 設定 build 的預設值並轉換路徑
+func validateBuildConfig(build *BuildConfig, localBinary string) error {
+	if build.Strategy == "" {
+		build.Strategy = defaultValues.Build.Strategy
+	}
+	switch {
+	case build.Strategy != BuildStrategyLocal && build.Strategy != BuildStrategyRemote:
+		return fmt.Errorf("strategy 必須是 '%s' 或 '%s'", BuildStrategyLocal, BuildStrategyRemote)
+	case build.Strategy == BuildStrategyLocal && build.Image != "":
+		return fmt.Errorf("image 只適用於 strategy: %s", BuildStrategyRemote)
+	}
+	if build.Command == "" {
+		if build.Strategy == BuildStrategyRemote {
+			build.Command = defaultValues.Build.RemoteCommand
+		} else {
+			build.Command = fmt.Sprintf(defaultValues.Build.Command, localBinary)
+		}
+	}
+	if build.WorkDir == "" {
+		build.WorkDir = defaultValues.Build.WorkDir
+	}
+	workDir, err := filepath.Abs(build.WorkDir)
+	if err != nil {
+		return fmt.Errorf("無法解析 workdir 路徑: %w", err)
+	}
+	build.WorkDir = workDir
+	if len(build.Watch) == 0 {
+		build.Watch = defaultValues.Build.Watch
+	}
+	if build.Ignore == nil {
+		build.Ignore = defaultValues.Build.Ignore
+	}
+	if build.Debounce <= 0 {
+		build.Debounce = defaultValues.Build.Debounce
+	}
+
+	// 配置載入時 map 的 key 會被轉為小寫，環境變數名稱慣例為大寫
+	env := make(map[string]string, len(build.Env))
+	for k, v := range build.Env {
+		env[strings.ToUpper(k)] = v
+	}
+	build.Env = env
+	return nil
+}
+
+// validateSyncConfig 設定 sync 的預設值並驗證
+func validateSyncConfig(sync *SyncConfig) error {
+	if sync.LocalDir == "" {
+		return fmt.Errorf("local_dir 為必要配置")
+	}
+	localDir, err := filepath.Abs(sync.LocalDir)
+	if err != nil {
+		return fmt.Errorf("無法解析 local_dir 路徑: %w", err)
+	}
+	sync.LocalDir = localDir
+	if !path.IsAbs(sync.ContainerDir) {
+		return fmt.Errorf("container_dir 必須是容器內的絕對路徑")
+	}
+	if sync.Ignore == nil {
+		sync.Ignore = defaultValues.Sync.Ignore
+	}
+	if sync.OnChange == "" {
+		sync.OnChange = defaultValues.Sync.OnChange
+	}
+	if sync.OnChange != SyncOnChangeRestart && sync.OnChange != SyncOnChangeSignal {
+		return fmt.Errorf("on_change 必須是 '%s' 或 '%s'", SyncOnChangeRestart, SyncOnChangeSignal)
+	}
+	sync.Signal = strings.TrimPrefix(strings.ToUpper(sync.Signal), "SIG")
+	if sync.Signal == "" {
+		sync.Signal = defaultValues.Sync.Signal
+	}
+	if sync.Debounce <= 0 {
+		sync.Debounce = defaultValues.Sync.Debounce
+	}
+	return nil
+}
+
+// artifactNamePattern artifact 名稱只能包含可直接作為目錄名稱的字元
+var artifactNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// validateArtifacts 設定 artifacts 的預設值並驗證
+func validateArtifacts(artifacts []Artifact) error {
+	names := make(map[string]bool)
+	containerPaths := make(map[string]string)
+	for i := range artifacts {
+		art := &artifacts[i]
+		if art.LocalPath == "" {
+			return fmt.Errorf("artifacts[%d] local_path 為必要配置", i)
+		}
+		localPath, err := filepath.Abs(art.LocalPath)
+		if err != nil {
+			return fmt.Errorf("artifacts[%d] 無法解析 local_path 路徑: %w", i, err)
+		}
+		art.LocalPath = localPath
+		if art.Name == "" {
+			art.Name = filepath.Base(localPath)
+		}
+		if !artifactNamePattern.MatchString(art.Name) || art.Name == "." || art.Name == ".." {
+			return fmt.Errorf("artifacts[%d] name '%s' 只能包含英數字、.、_ 與 -", i, art.Name)
+		}
+		if names[art.Name] {
+			return fmt.Errorf("artifacts 名稱 '%s' 重複", art.Name)
+		}
+		names[art.Name] = true
+
+		if !path.IsAbs(art.ContainerPath) {
+			return fmt.Errorf("artifacts '%s' container_path 必須是容器內的絕對路徑", art.Name)
+		}
+		if other, ok := containerPaths[art.ContainerPath]; ok {
+			return fmt.Errorf("artifacts '%s' 與 '%s' 掛載到相同的 container_path %s", art.Name, other, art.ContainerPath)
+		}
+		containerPaths[art.ContainerPath] = art.Name
+
+		if art.Mode != "" {
+			if mode, err := strconv.ParseUint(art.Mode, 8, 32); err != nil || mode > 0777 {
+				return fmt.Errorf("artifacts '%s' mode 必須是八進位權限，例如 0644", art.Name)
+			}
+		}
+	}
+	return nil
+}
+
 // validateConfig 驗證配置
 // validateConfig 驗證配置
 func validateConfig(cfg *Config) error {
@@ -268,21 +712,45 @@ func validateConfig(cfg *Config) error {
 		return fmt.Errorf("必須至少定義一個 host")
 	}
 
+	if err := validateDlvConfig(&cfg.DlvConfig); err != nil {
+		return fmt.Errorf("dlv_config: %w", err)
+	}
+
 	// 驗證每個組件
 	for name, comp := range cfg.Components {
-		if comp.LocalBinary == "" {
-			return fmt.Errorf("component '%s': local_binary 為必要配置", name)
+		if comp.Kind == "" {
+			comp.Kind = ComponentKindBinary
+		}
+		switch comp.Kind {
+		case ComponentKindBinary:
+			if comp.LocalBinary == "" {
+				return fmt.Errorf("component '%s': local_binary 為必要配置", name)
+			}
+		case ComponentKindSync:
+			if comp.Sync == nil {
+				return fmt.Errorf("component '%s': sync 類型需要 sync 配置", name)
+			}
+			if err := validateSyncConfig(comp.Sync); err != nil {
+				return fmt.Errorf("component '%s': sync: %w", name, err)
+			}
+			if comp.Build != nil {
+				return fmt.Errorf("component '%s': sync 類型不支援 build", name)
+			}
+		default:
+			return fmt.Errorf("component '%s': kind 必須是 '%s' 或 '%s'", name, ComponentKindBinary, ComponentKindSync)
 		}
 		if comp.TargetService == "" {
 			return fmt.Errorf("component '%s': target_service 為必要配置", name)
 		}
 
 		// 驗證並轉換路徑
-		binaryPath, err := filepath.Abs(comp.LocalBinary)
-		if err != nil {
-			return fmt.Errorf("component '%s': 無法解析 local_binary 路徑: %w", name, err)
+		if comp.LocalBinary != "" {
+			binaryPath, err := filepath.Abs(comp.LocalBinary)
+			if err != nil {
+				return fmt.Errorf("component '%s': 無法解析 local_binary 路徑: %w", name, err)
+			}
+			comp.LocalBinary = binaryPath
 		}
-		comp.LocalBinary = binaryPath
 
 		// 設定組件預設值
 		if comp.ContainerBinaryPath == "" {
@@ -291,9 +759,130 @@ func validateConfig(cfg *Config) error {
 		if comp.DebuggerPort == 0 {
 			comp.DebuggerPort = defaultValues.Component.DebuggerPort
 		}
-		//if comp.ExtraPorts == nil {
-		//	comp.ExtraPorts = defaultValues.Component.ExtraPorts
-		//}
+		if comp.DebuggerPortPolicy == "" {
+			comp.DebuggerPortPolicy = defaultValues.Component.PortPolicy
+		}
+		if !validPortPolicy(comp.DebuggerPortPolicy) {
+			return fmt.Errorf("component '%s': debugger_port_policy 必須是 '%s'、'%s' 或 '%s'", name, PortPolicyFixed, PortPolicyNextFree, PortPolicyEphemeral)
+		}
+		if comp.ExtraPorts == nil {
+			comp.ExtraPorts = defaultValues.Component.ExtraPorts
+		}
+		for _, port := range comp.ExtraPorts {
+			if port <= 0 || port > 65535 {
+				return fmt.Errorf("component '%s': extra_ports 包含無效端口 %d", name, port)
+			}
+		}
+
+		if comp.History == nil {
+			history := defaultValues.Component.History
+			comp.History = &history
+		}
+		if *comp.History < 0 {
+			return fmt.Errorf("component '%s': history 不可為負數", name)
+		}
+
+		// 設定代理預設值
+		if comp.Proxy.Port == 0 {
+			comp.Proxy.Port = defaultValues.Component.ProxyPort
+		}
+		if comp.Proxy.PortPolicy == "" {
+			comp.Proxy.PortPolicy = defaultValues.Component.PortPolicy
+		}
+		if !validPortPolicy(comp.Proxy.PortPolicy) {
+			return fmt.Errorf("component '%s': proxy.port_policy 必須是 '%s'、'%s' 或 '%s'", name, PortPolicyFixed, PortPolicyNextFree, PortPolicyEphemeral)
+		}
+
+		// 驗證反向端口轉發
+		remotePorts := make(map[int]string)
+		for i := range comp.ReverseForwards {
+			rev := &comp.ReverseForwards[i]
+			if rev.LocalPort <= 0 || rev.LocalPort > 65535 {
+				return fmt.Errorf("component '%s': reverse_forwards[%d] local_port 無效", name, i)
+			}
+			if rev.RemotePort == 0 {
+				rev.RemotePort = rev.LocalPort
+			}
+			if rev.RemotePort < 0 || rev.RemotePort > 65535 {
+				return fmt.Errorf("component '%s': reverse_forwards[%d] remote_port 無效", name, i)
+			}
+			if rev.LocalHost == "" {
+				rev.LocalHost = defaultValues.Component.ReverseLocalHost
+			}
+			// 注入容器時保留空值，啟動時改用 bridge 網路的 gateway 地址，只讓容器連入而不對外開放
+			if rev.RemoteBind == "" && !rev.Injected() {
+				rev.RemoteBind = defaultValues.Component.ReverseRemoteBind
+			}
+			if rev.Name == "" {
+				rev.Name = fmt.Sprintf("reverse-%d", rev.LocalPort)
+			}
+			if other, ok := remotePorts[rev.RemotePort]; ok {
+				return fmt.Errorf("component '%s': reverse_forwards '%s' 與 '%s' 使用相同的遠端端口 %d", name, rev.Name, other, rev.RemotePort)
+			}
+			remotePorts[rev.RemotePort] = rev.Name
+		}
+
+		// 驗證端口轉發
+		localPorts := make(map[int]string)
+		for i := range comp.Forwards {
+			fwd := &comp.Forwards[i]
+			if fwd.ContainerPort <= 0 || fwd.ContainerPort > 65535 {
+				return fmt.Errorf("component '%s': forwards[%d] container_port 無效", name, i)
+			}
+			if fwd.LocalPort == 0 {
+				fwd.LocalPort = fwd.ContainerPort
+			}
+			if fwd.LocalPort < 0 || fwd.LocalPort > 65535 {
+				return fmt.Errorf("component '%s': forwards[%d] local_port 無效", name, i)
+			}
+			if fwd.Protocol == "" {
+				fwd.Protocol = defaultValues.Component.ForwardProtocol
+			}
+			if fwd.Protocol != "tcp" {
+				return fmt.Errorf("component '%s': forwards[%d] 目前僅支援 tcp 協定", name, i)
+			}
+			if fwd.Name == "" {
+				fwd.Name = fmt.Sprintf("port-%d", fwd.ContainerPort)
+			}
+			if fwd.PortPolicy == "" {
+				fwd.PortPolicy = defaultValues.Component.PortPolicy
+			}
+			if !validPortPolicy(fwd.PortPolicy) {
+				return fmt.Errorf("component '%s': forwards '%s' port_policy 必須是 '%s'、'%s' 或 '%s'", name, fwd.Name, PortPolicyFixed, PortPolicyNextFree, PortPolicyEphemeral)
+			}
+			// 只有固定端口會在設定階段衝突，其他策略在建立時自動避開已佔用的端口
+			if fwd.PortPolicy != PortPolicyFixed {
+				continue
+			}
+			if other, ok := localPorts[fwd.LocalPort]; ok {
+				return fmt.Errorf("component '%s': forwards '%s' 與 '%s' 使用相同的本地端口 %d", name, fwd.Name, other, fwd.LocalPort)
+			}
+			if fwd.LocalPort == comp.DebuggerPort && comp.DebuggerPortPolicy == PortPolicyFixed {
+				return fmt.Errorf("component '%s': forwards '%s' 的本地端口 %d 與 debugger_port 衝突", name, fwd.Name, fwd.LocalPort)
+			}
+			localPorts[fwd.LocalPort] = fwd.Name
+		}
+
+		if err := validateArtifacts(comp.Artifacts); err != nil {
+			return fmt.Errorf("component '%s': %w", name, err)
+		}
+		for _, art := range comp.Artifacts {
+			if comp.Kind == ComponentKindBinary && art.ContainerPath == comp.ContainerBinaryPath {
+				return fmt.Errorf("component '%s': artifacts '%s' 的 container_path 與 container_binary_path 相同", name, art.Name)
+			}
+		}
+
+		if comp.Build != nil {
+			if err := validateBuildConfig(comp.Build, comp.LocalBinary); err != nil {
+				return fmt.Errorf("component '%s': build: %w", name, err)
+			}
+		}
+
+		if comp.DlvConfig != nil {
+			if err := validateDlvConfig(comp.DlvConfig); err != nil {
+				return fmt.Errorf("component '%s': dlv_config: %w", name, err)
+			}
+		}
 
 		// LogFile, InitialScripts, DlvConfig 如果為 nil，表示使用全局預設值
 		// 在 InteractiveSelect 時會處理合併邏輯
@@ -354,6 +943,36 @@ func validateConfig(cfg *Config) error {
 			host.DockerComposeCommand = defaultValues.Host.DockerComposeCommand
 		}
 
+		// 設定容器操作後端預設值
+		if host.DockerBackend == "" {
+			host.DockerBackend = defaultValues.Host.DockerBackend
+		}
+		if host.DockerBackend != DockerBackendCLI && host.DockerBackend != DockerBackendAPI {
+			return fmt.Errorf("host '%s': docker_backend 必須是 '%s' 或 '%s'", name, DockerBackendCLI, DockerBackendAPI)
+		}
+		if host.DockerSocket == "" {
+			host.DockerSocket = defaultValues.Host.DockerSocket
+		}
+
+		// 設定 tunnel 連接方式預設值
+		if host.TunnelTarget == "" {
+			host.TunnelTarget = defaultValues.Host.TunnelTarget
+		}
+		if host.TunnelTarget != TunnelTargetHost && host.TunnelTarget != TunnelTargetContainer {
+			return fmt.Errorf("host '%s': tunnel_target 必須是 '%s' 或 '%s'", name, TunnelTargetHost, TunnelTargetContainer)
+		}
+
+		// 設定逾時預設值
+		if host.Timeouts.Command == 0 {
+			host.Timeouts.Command = defaultValues.Host.Timeouts.Command
+		}
+		if host.Timeouts.Upload == 0 {
+			host.Timeouts.Upload = defaultValues.Host.Timeouts.Upload
+		}
+		if host.Timeouts.Cleanup == 0 {
+			host.Timeouts.Cleanup = defaultValues.Host.Timeouts.Cleanup
+		}
+
 		// 確保 Projects map 存在
 		if host.Projects == nil {
 			host.Projects = make(map[string]Project)
@@ -22,10 +22,11 @@ func (cfg *Config) selectMultiConfig() (*RuntimeConfig, error) {
 	componentOptions := make([]huh.Option[string], len(componentKeys))
 	for i, key := range componentKeys {
 		c := cfg.Components[key]
-		componentOptions[i] = huh.NewOption(
-			fmt.Sprintf("%s (service: %s, binary: %s)", c.Name, c.TargetService, c.LocalBinary),
-			key,
-		)
+		label := fmt.Sprintf("%s (service: %s, binary: %s)", c.Name, c.TargetService, c.LocalBinary)
+		if c.Kind == ComponentKindSync && c.Sync != nil {
+			label = fmt.Sprintf("%s (service: %s, sync: %s)", c.Name, c.TargetService, c.Sync.LocalDir)
+		}
+		componentOptions[i] = huh.NewOption(label, key)
 	}
 
 	// 準備主機選項
@@ -111,9 +112,21 @@ func (cfg *Config) selectMultiConfig() (*RuntimeConfig, error) {
 	if selectedComponent.DlvConfig == nil {
 		selectedComponent.DlvConfig = &cfg.DlvConfig
 	}
+	if selectedComponent.DlvConfig.ReadyTimeout == 0 {
+		selectedComponent.DlvConfig.ReadyTimeout = defaultValues.DlvConfig.ReadyTimeout
+	}
+	// sync 類型沒有可調試的 Go 執行檔，不啟動 dlv（複製一份，避免修改全局預設值）
+	if selectedComponent.Kind == ComponentKindSync && selectedComponent.DlvConfig.Enabled {
+		dlv := *selectedComponent.DlvConfig
+		dlv.Enabled = false
+		selectedComponent.DlvConfig = &dlv
+	}
 
 	// 建立 RuntimeConfig（現在 selectedComponent 保證所有欄位都有值）
 	rc := &RuntimeConfig{
+		ComponentKey:         componentName,
+		HostKey:              hostName,
+		ProjectKey:           projectName,
 		Mode:                 selectedHost.Mode,
 		Component:            selectedComponent,
 		Host:                 selectedHost,
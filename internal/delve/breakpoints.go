@@ -0,0 +1,139 @@
+package delve
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Breakpoint Delve api.Breakpoint 中重新建立斷點需要的欄位
+// LoadArgs / LoadLocals 原樣保留，不需要解析
+type Breakpoint struct {
+	ID           int             `json:"id"`
+	Name         string          `json:"name"`
+	File         string          `json:"file"`
+	Line         int             `json:"line"`
+	FunctionName string          `json:"functionName,omitempty"`
+	Cond         string          `json:"Cond"`
+	HitCond      string          `json:"HitCond"`
+	Tracepoint   bool            `json:"continue"`
+	TraceReturn  bool            `json:"traceReturn,omitempty"`
+	Goroutine    bool            `json:"goroutine"`
+	Stacktrace   int             `json:"stacktrace"`
+	Variables    []string        `json:"variables,omitempty"`
+	LoadArgs     json.RawMessage `json:"LoadArgs,omitempty"`
+	LoadLocals   json.RawMessage `json:"LoadLocals,omitempty"`
+	WatchExpr    string          `json:"WatchExpr,omitempty"`
+	Disabled     bool            `json:"disabled"`
+}
+
+// Location 斷點位置說明，用於日誌
+func (bp Breakpoint) Location() string {
+	if bp.Name != "" {
+		return fmt.Sprintf("%s (%s:%d)", bp.Name, bp.File, bp.Line)
+	}
+	return fmt.Sprintf("%s:%d", bp.File, bp.Line)
+}
+
+// Restorable 是否能在新的 Delve 上重新建立
+// 內部斷點（ID <= 0，例如 unrecovered-panic）由 Delve 自動建立，watchpoint 依附於記憶體位址，兩者都不保存
+func (bp Breakpoint) Restorable() bool {
+	return bp.ID > 0 && bp.WatchExpr == "" && bp.File != "" && bp.Line > 0
+}
+
+// DebuggerState Delve api.DebuggerState 中需要的欄位
+type DebuggerState struct {
+	Pid     int  `json:"Pid"`
+	Running bool `json:"Running"`
+	Exited  bool `json:"exited"`
+}
+
+type debuggerCommand struct {
+	Name string `json:"name"`
+}
+
+type stateIn struct {
+	NonBlocking bool
+}
+
+type stateOut struct {
+	State *DebuggerState
+}
+
+type commandOut struct {
+	State DebuggerState
+}
+
+type listBreakpointsIn struct {
+	All bool
+}
+
+type listBreakpointsOut struct {
+	Breakpoints []*Breakpoint
+}
+
+type createBreakpointIn struct {
+	Breakpoint Breakpoint
+}
+
+type createBreakpointOut struct {
+	Breakpoint Breakpoint
+}
+
+// State 返回目標程式狀態；目標程式執行中也會立即返回
+func (c *Client) State(ctx context.Context) (*DebuggerState, error) {
+	var out stateOut
+	if err := c.call(ctx, "State", stateIn{NonBlocking: true}, &out); err != nil {
+		return nil, err
+	}
+	if out.State == nil {
+		return nil, fmt.Errorf("Delve 未返回狀態")
+	}
+	return out.State, nil
+}
+
+// Halt 暫停目標程式；Delve 在目標程式執行中不處理其他請求，讀取斷點前需要先暫停
+func (c *Client) Halt(ctx context.Context) error {
+	var out commandOut
+	return c.call(ctx, "Command", debuggerCommand{Name: "halt"}, &out)
+}
+
+// ListBreakpoints 返回使用者設定的斷點（不含 Delve 內部斷點與 watchpoint）
+func (c *Client) ListBreakpoints(ctx context.Context) ([]Breakpoint, error) {
+	var out listBreakpointsOut
+	if err := c.call(ctx, "ListBreakpoints", listBreakpointsIn{}, &out); err != nil {
+		return nil, err
+	}
+	breakpoints := make([]Breakpoint, 0, len(out.Breakpoints))
+	for _, bp := range out.Breakpoints {
+		if bp != nil && bp.Restorable() {
+			breakpoints = append(breakpoints, *bp)
+		}
+	}
+	return breakpoints, nil
+}
+
+// CreateBreakpoint 依檔案與行號重新建立斷點（新版本執行檔的位址可能不同，不沿用舊位址與 ID）
+func (c *Client) CreateBreakpoint(ctx context.Context, bp Breakpoint) (*Breakpoint, error) {
+	bp.ID = 0
+	in := createBreakpointIn{Breakpoint: bp}
+	var out createBreakpointOut
+	if err := c.call(ctx, "CreateBreakpoint", in, &out); err != nil {
+		return nil, err
+	}
+	return &out.Breakpoint, nil
+}
+
+// Continue 讓目標程式繼續執行，不等待目標程式停止
+// Delve 的 continue 會阻塞到下一次停止，因此只確認請求已送出；之後即可關閉客戶端
+func (c *Client) Continue() error {
+	call := c.rpc.Go("RPCServer.Command", debuggerCommand{Name: "continue"}, &commandOut{}, nil)
+	select {
+	case <-call.Done:
+		if call.Error != nil {
+			return fmt.Errorf("呼叫 Command(continue) 失敗: %w", call.Error)
+		}
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package delve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+)
+
+// Client Delve JSON-RPC（API v2）客戶端
+// Delve headless 模式使用 net/rpc 的 JSON-RPC 1.0 編碼，方法名稱為 RPCServer.<方法>
+type Client struct {
+	conn net.Conn
+	rpc  *rpc.Client
+}
+
+// VersionInfo RPCServer.GetVersion 的回傳內容
+type VersionInfo struct {
+	DelveVersion    string
+	APIVersion      int
+	Backend         string
+	TargetGoVersion string
+}
+
+// Dial 連接 Delve headless server
+func Dial(ctx context.Context, addr string) (*Client, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("連接 Delve 失敗: %w", err)
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient 以既有連線建立客戶端，例如透過 tunnel 建立的連線
+func NewClient(conn net.Conn) *Client {
+	return &Client{
+		conn: conn,
+		rpc:  jsonrpc.NewClient(conn),
+	}
+}
+
+// call 呼叫 RPC 方法，ctx 結束時關閉連線以中斷等待中的呼叫
+func (c *Client) call(ctx context.Context, method string, args, reply any) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() {
+		c.conn.Close()
+	})
+	defer stop()
+
+	if err := c.rpc.Call("RPCServer."+method, args, reply); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("呼叫 %s 失敗: %w", method, err)
+	}
+	return nil
+}
+
+// GetVersion 取得 Delve 與目標程式的版本資訊，可作為握手確認 Delve 已可接受連線
+func (c *Client) GetVersion(ctx context.Context) (*VersionInfo, error) {
+	var out VersionInfo
+	if err := c.call(ctx, "GetVersion", struct{}{}, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Close 關閉連線（不會停止 Delve 或目標程式）
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
@@ -0,0 +1,92 @@
+package delve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Phase debugger 的就緒狀態
+type Phase string
+
+const (
+	PhaseDisabled Phase = "disabled" // 未啟用 debugger
+	PhaseStarting Phase = "starting" // 等待 Delve 接受連線
+	PhaseReady    Phase = "ready"    // 握手成功，IDE 可以連接
+	PhasePaused   Phase = "paused"   // 握手成功，目標程式暫停中，等待 debugger 連接後繼續
+	PhaseFailed   Phase = "failed"   // Delve 未在時限內就緒或容器已退出
+)
+
+// Status debugger 狀態與說明
+type Status struct {
+	Phase   Phase
+	Addr    string       // IDE 連接的本地地址
+	Version *VersionInfo // 就緒時的版本資訊
+	Reason  string       // 失敗原因，或暫停後無法繼續執行的原因
+}
+
+const (
+	// probeInterval 兩次握手嘗試的間隔
+	probeInterval = 500 * time.Millisecond
+	// probeAttemptTimeout 單次握手的逾時
+	probeAttemptTimeout = 3 * time.Second
+)
+
+// ProbeOptions 就緒探測選項
+type ProbeOptions struct {
+	// Timeout 等待就緒的上限，<= 0 表示不限制
+	Timeout time.Duration
+	// Check 每次握手失敗後呼叫，返回錯誤時立即判定為失敗（例如開發容器已退出）
+	Check func(ctx context.Context) error
+}
+
+// WaitReady 透過 addr 重複執行 GetVersion 握手，直到成功、逾時、Check 失敗或 ctx 結束
+func WaitReady(ctx context.Context, addr string, opts ProbeOptions) (*VersionInfo, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	var lastErr error
+	for {
+		version, err := probeOnce(ctx, addr)
+		if err == nil {
+			return version, nil
+		}
+		if ctx.Err() == nil {
+			lastErr = err
+		}
+
+		if opts.Check != nil && ctx.Err() == nil {
+			if err := opts.Check(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if lastErr == nil {
+					lastErr = ctx.Err()
+				}
+				return nil, fmt.Errorf("Delve 在 %s 內未就緒: %w", opts.Timeout, lastErr)
+			}
+			return nil, ctx.Err()
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+func probeOnce(ctx context.Context, addr string) (*VersionInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeAttemptTimeout)
+	defer cancel()
+
+	client, err := Dial(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.GetVersion(ctx)
+}
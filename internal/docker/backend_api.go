@@ -0,0 +1,422 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// apiVersion 使用的 Docker Engine API 版本（Docker 20.10+）
+const apiVersion = "v1.41"
+
+// apiBackend 直接呼叫 Docker Engine API 的後端實作
+// 連線經由 Executor.Dial 建立：本地直接連 unix socket，遠端透過 SSH 轉發 unix socket
+// 注意：SSH 轉發以登入使用者身份開啟 socket，use_sudo 對此後端無效
+type apiBackend struct {
+	client  *http.Client
+	timeout time.Duration
+}
+
+func newAPIBackend(exec executor.Executor, socketPath string, timeout time.Duration) *apiBackend {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return exec.Dial(ctx, "unix", socketPath)
+		},
+		DisableCompression: true,
+		MaxIdleConns:       4,
+		IdleConnTimeout:    30 * time.Second,
+	}
+
+	return &apiBackend{
+		client:  &http.Client{Transport: transport},
+		timeout: timeout,
+	}
+}
+
+// apiError Docker Engine API 錯誤回應
+type apiError struct {
+	Message string `json:"message"`
+}
+
+// request 發送 API 請求，stream 為 false 時套用單次操作逾時並讀完回應
+func (b *apiBackend) request(ctx context.Context, method, path string, query url.Values, body any, stream bool) (*http.Response, context.CancelFunc, error) {
+	cancel := context.CancelFunc(func() {})
+	if !stream && b.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("編碼請求失敗: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	endpoint := fmt.Sprintf("http://docker/%s%s", apiVersion, path)
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("建立請求失敗: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("Docker API %s %s 失敗: %w", method, path, err)
+	}
+
+	// 304 表示容器已處於目標狀態（例如已啟動 / 已停止），視為成功
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotModified {
+		defer resp.Body.Close()
+		defer cancel()
+		var apiErr apiError
+		data, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return nil, nil, fmt.Errorf("Docker API %s %s 失敗 (%d): %s", method, path, resp.StatusCode, apiErr.Message)
+	}
+
+	return resp, cancel, nil
+}
+
+// call 發送非流式請求，out 不為 nil 時解析 JSON 回應
+func (b *apiBackend) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	resp, cancel, err := b.request(ctx, method, path, query, body, false)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	if out == nil || resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析 Docker API 回應失敗: %w", err)
+	}
+	return nil
+}
+
+func (b *apiBackend) ListContainers(ctx context.Context, filter ListFilter) ([]string, error) {
+	query := url.Values{}
+	if filter.All {
+		query.Set("all", "1")
+	}
+	filters := make(map[string][]string)
+	if filter.Name != "" {
+		filters["name"] = []string{fmt.Sprintf("^/%s$", filter.Name)}
+	}
+	if filter.Network != "" {
+		filters["network"] = []string{filter.Network}
+	}
+	if len(filters) > 0 {
+		encoded, _ := json.Marshal(filters)
+		query.Set("filters", string(encoded))
+	}
+
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := b.call(ctx, http.MethodGet, "/containers/json", query, nil, &containers); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+func (b *apiBackend) InspectContainer(ctx context.Context, nameOrID string) (*ContainerInspect, error) {
+	var info ContainerInspect
+	if err := b.call(ctx, http.MethodGet, "/containers/"+url.PathEscape(nameOrID)+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// apiCreateRequest POST /containers/create 的請求內容
+type apiCreateRequest struct {
+	Image            string               `json:"Image"`
+	Env              []string             `json:"Env,omitempty"`
+	Cmd              []string             `json:"Cmd,omitempty"`
+	WorkingDir       string               `json:"WorkingDir,omitempty"`
+	Labels           map[string]string    `json:"Labels,omitempty"`
+	ExposedPorts     map[string]struct{}  `json:"ExposedPorts,omitempty"`
+	HostConfig       apiHostConfig        `json:"HostConfig"`
+	NetworkingConfig *apiNetworkingConfig `json:"NetworkingConfig,omitempty"`
+}
+
+type apiHostConfig struct {
+	Binds        []string                    `json:"Binds,omitempty"`
+	PortBindings map[string][]apiPortBinding `json:"PortBindings,omitempty"`
+	NetworkMode  string                      `json:"NetworkMode,omitempty"`
+	ExtraHosts   []string                    `json:"ExtraHosts,omitempty"`
+}
+
+type apiPortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+type apiNetworkingConfig struct {
+	EndpointsConfig map[string]struct{} `json:"EndpointsConfig"`
+}
+
+// PullImage 的回應為 JSON 進度訊息串流，失敗時會在訊息中帶有 error 欄位
+func (b *apiBackend) PullImage(ctx context.Context, image, platform string) error {
+	query := url.Values{"fromImage": {image}}
+	if platform != "" {
+		query.Set("platform", platform)
+	}
+	resp, cancel, err := b.request(ctx, http.MethodPost, "/images/create", query, nil, true)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Error string `json:"error"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("讀取拉取進度失敗: %w", err)
+		}
+		if message.Error != "" {
+			return fmt.Errorf("拉取映像 %s 失敗: %s", image, message.Error)
+		}
+	}
+}
+
+func (b *apiBackend) CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error) {
+	req := apiCreateRequest{
+		Image:      spec.Image,
+		Env:        spec.Env,
+		Cmd:        spec.Cmd,
+		WorkingDir: spec.WorkingDir,
+		Labels:     spec.Labels,
+		HostConfig: apiHostConfig{
+			Binds:      spec.Binds,
+			ExtraHosts: spec.ExtraHosts,
+		},
+	}
+
+	if len(spec.PortBindings) > 0 {
+		req.ExposedPorts = make(map[string]struct{})
+		req.HostConfig.PortBindings = make(map[string][]apiPortBinding)
+		for _, binding := range spec.PortBindings {
+			key := binding.portKey()
+			req.ExposedPorts[key] = struct{}{}
+			hostIP := binding.HostIP
+			if hostIP == "0.0.0.0" {
+				hostIP = ""
+			}
+			req.HostConfig.PortBindings[key] = append(req.HostConfig.PortBindings[key], apiPortBinding{
+				HostIP:   hostIP,
+				HostPort: binding.HostPort,
+			})
+		}
+	}
+
+	// 建立時只能指定一個網路，其餘網路在建立後連接
+	if len(spec.Networks) > 0 {
+		req.HostConfig.NetworkMode = spec.Networks[0]
+		req.NetworkingConfig = &apiNetworkingConfig{
+			EndpointsConfig: map[string]struct{}{spec.Networks[0]: {}},
+		}
+	}
+
+	var created struct {
+		ID       string   `json:"Id"`
+		Warnings []string `json:"Warnings"`
+	}
+	query := url.Values{"name": {spec.Name}}
+	if spec.Platform != "" {
+		query.Set("platform", spec.Platform)
+	}
+	if err := b.call(ctx, http.MethodPost, "/containers/create", query, req, &created); err != nil {
+		return "", err
+	}
+
+	for _, network := range spec.Networks[min(1, len(spec.Networks)):] {
+		body := map[string]string{"Container": created.ID}
+		if err := b.call(ctx, http.MethodPost, "/networks/"+url.PathEscape(network)+"/connect", nil, body, nil); err != nil {
+			return created.ID, fmt.Errorf("連接網路 %s 失敗: %w", network, err)
+		}
+	}
+
+	return created.ID, nil
+}
+
+func (b *apiBackend) StartContainer(ctx context.Context, nameOrID string) error {
+	return b.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/start", nil, nil, nil)
+}
+
+func (b *apiBackend) StopContainer(ctx context.Context, nameOrID string) error {
+	return b.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/stop", nil, nil, nil)
+}
+
+func (b *apiBackend) RestartContainer(ctx context.Context, nameOrID string) error {
+	return b.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/restart", nil, nil, nil)
+}
+
+func (b *apiBackend) SignalContainer(ctx context.Context, nameOrID, signal string) error {
+	query := url.Values{"signal": {signal}}
+	return b.call(ctx, http.MethodPost, "/containers/"+url.PathEscape(nameOrID)+"/kill", query, nil, nil)
+}
+
+func (b *apiBackend) RemoveContainer(ctx context.Context, nameOrID string) error {
+	query := url.Values{"force": {"1"}}
+	return b.call(ctx, http.MethodDelete, "/containers/"+url.PathEscape(nameOrID), query, nil, nil)
+}
+
+func (b *apiBackend) Logs(ctx context.Context, nameOrID string, opts LogsOptions) (*LogStream, error) {
+	info, err := b.InspectContainer(ctx, nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if opts.Follow {
+		query.Set("follow", "1")
+	}
+	if opts.Tail > 0 {
+		query.Set("tail", fmt.Sprint(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		query.Set("since", fmt.Sprintf("%d.%09d", opts.Since.Unix(), opts.Since.Nanosecond()))
+	}
+
+	ctx, cancelStream := context.WithCancel(ctx)
+	resp, cancel, err := b.request(ctx, http.MethodGet, "/containers/"+url.PathEscape(nameOrID)+"/logs", query, nil, true)
+	if err != nil {
+		cancelStream()
+		return nil, err
+	}
+
+	stdoutReader, stdoutWriter := io.Pipe()
+	stderrReader, stderrWriter := io.Pipe()
+	done := make(chan error, 1)
+
+	go func() {
+		defer cancel()
+		defer resp.Body.Close()
+
+		var err error
+		if info.Config.Tty {
+			// TTY 容器的日誌不分流，全部視為 stdout
+			_, err = io.Copy(stdoutWriter, resp.Body)
+		} else {
+			err = demuxStream(resp.Body, stdoutWriter, stderrWriter)
+		}
+		stdoutWriter.CloseWithError(err)
+		stderrWriter.CloseWithError(err)
+		done <- err
+	}()
+
+	return &LogStream{
+		Stdout: stdoutReader,
+		Stderr: stderrReader,
+		wait: func() error {
+			err := <-done
+			done <- err
+			return err
+		},
+		close: func() error {
+			cancelStream()
+			return nil
+		},
+	}, nil
+}
+
+// demuxStream 解析 Docker 多工串流格式：每個區塊以 8 bytes 標頭開始
+// 標頭第 1 byte 為串流類型（1=stdout, 2=stderr），最後 4 bytes 為大端序的區塊長度
+func demuxStream(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		var dst io.Writer
+		switch header[0] {
+		case 2:
+			dst = stderr
+		default:
+			dst = stdout
+		}
+		if _, err := io.CopyN(dst, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *apiBackend) Events(ctx context.Context, containerName string) (<-chan Event, <-chan error) {
+	eventCh := make(chan Event)
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer close(eventCh)
+
+		filters, _ := json.Marshal(map[string][]string{
+			"type":      {"container"},
+			"container": {containerName},
+		})
+		resp, cancel, err := b.request(ctx, http.MethodGet, "/events", url.Values{"filters": {string(filters)}}, nil, true)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer cancel()
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		for {
+			var event Event
+			if err := decoder.Decode(&event); err != nil {
+				if ctx.Err() == nil {
+					errCh <- fmt.Errorf("事件監聽中斷: %w", err)
+				}
+				return
+			}
+			select {
+			case eventCh <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return eventCh, errCh
+}
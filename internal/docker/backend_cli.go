@@ -0,0 +1,297 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// cliBackend 透過 Executor 執行 docker CLI 的後端實作
+type cliBackend struct {
+	executor   executor.Executor
+	cmdBuilder *CommandBuilder
+}
+
+func newCLIBackend(exec executor.Executor, cmdBuilder *CommandBuilder) *cliBackend {
+	return &cliBackend{
+		executor:   exec,
+		cmdBuilder: cmdBuilder,
+	}
+}
+
+func (b *cliBackend) run(ctx context.Context, args ...string) (string, error) {
+	return b.executor.Execute(ctx, b.cmdBuilder.Docker(args...))
+}
+
+func (b *cliBackend) ListContainers(ctx context.Context, filter ListFilter) ([]string, error) {
+	args := []string{"ps", "-q"}
+	if filter.All {
+		args = append(args, "-a")
+	}
+	if filter.Name != "" {
+		args = append(args, fmt.Sprintf("--filter name=^/%s$", filter.Name))
+	}
+	if filter.Network != "" {
+		args = append(args, fmt.Sprintf("--filter network=%s", shellQuote(filter.Network)))
+	}
+
+	output, err := b.run(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(output), nil
+}
+
+func (b *cliBackend) InspectContainer(ctx context.Context, nameOrID string) (*ContainerInspect, error) {
+	output, err := b.run(ctx, "inspect", "--type", "container", nameOrID)
+	if err != nil {
+		return nil, err
+	}
+
+	var inspectData []ContainerInspect
+	if err := json.Unmarshal([]byte(output), &inspectData); err != nil {
+		return nil, fmt.Errorf("解析容器資訊失敗: %w", err)
+	}
+	if len(inspectData) == 0 {
+		return nil, fmt.Errorf("找不到容器 %s", nameOrID)
+	}
+	return &inspectData[0], nil
+}
+
+func (b *cliBackend) PullImage(ctx context.Context, image, platform string) error {
+	args := []string{"pull", "--quiet"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	_, err := b.runStreamed(ctx, b.cmdBuilder.Docker(append(args, shellQuote(image))...))
+	return err
+}
+
+func (b *cliBackend) CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error) {
+	cmdParts := []string{"create", fmt.Sprintf("--name %s", spec.Name)}
+
+	// 環境變數
+	for _, env := range spec.Env {
+		cmdParts = append(cmdParts, fmt.Sprintf("-e %s", shellQuote(env)))
+	}
+
+	// 掛載
+	for _, bind := range spec.Binds {
+		cmdParts = append(cmdParts, fmt.Sprintf("-v %s", shellQuote(bind)))
+	}
+
+	// 端口映射
+	for _, binding := range spec.PortBindings {
+		cmdParts = append(cmdParts, fmt.Sprintf("-p %s", binding.publishSpec()))
+	}
+
+	// 網路
+	for _, network := range spec.Networks {
+		cmdParts = append(cmdParts, fmt.Sprintf("--network %s", network))
+	}
+
+	// 額外的 hosts 項目
+	for _, host := range spec.ExtraHosts {
+		cmdParts = append(cmdParts, fmt.Sprintf("--add-host %s", shellQuote(host)))
+	}
+
+	// Working Directory
+	if spec.WorkingDir != "" {
+		cmdParts = append(cmdParts, fmt.Sprintf("-w %s", shellQuote(spec.WorkingDir)))
+	}
+
+	// 標籤（排序後輸出，讓命令內容穩定）
+	labelKeys := make([]string, 0, len(spec.Labels))
+	for k := range spec.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		cmdParts = append(cmdParts, fmt.Sprintf("-l %s", shellQuote(k+"="+spec.Labels[k])))
+	}
+
+	// 平台
+	if spec.Platform != "" {
+		cmdParts = append(cmdParts, fmt.Sprintf("--platform %s", spec.Platform))
+	}
+
+	// 映像與命令
+	cmdParts = append(cmdParts, spec.Image)
+	for _, arg := range spec.Cmd {
+		cmdParts = append(cmdParts, shellQuote(arg))
+	}
+
+	// 映像不存在時 docker create 會自動拉取，與 PullImage 同樣不受 timeouts.command 限制
+	cmd := b.cmdBuilder.Docker(cmdParts...)
+	log.Printf("執行命令: %s", cmd)
+	output, err := b.runStreamed(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
+
+func (b *cliBackend) StartContainer(ctx context.Context, nameOrID string) error {
+	_, err := b.run(ctx, "start", nameOrID)
+	return err
+}
+
+func (b *cliBackend) StopContainer(ctx context.Context, nameOrID string) error {
+	_, err := b.run(ctx, "stop", nameOrID)
+	return err
+}
+
+func (b *cliBackend) RestartContainer(ctx context.Context, nameOrID string) error {
+	_, err := b.run(ctx, "restart", nameOrID)
+	return err
+}
+
+func (b *cliBackend) SignalContainer(ctx context.Context, nameOrID, signal string) error {
+	_, err := b.run(ctx, "kill", "--signal", signal, nameOrID)
+	return err
+}
+
+func (b *cliBackend) RemoveContainer(ctx context.Context, nameOrID string) error {
+	_, err := b.run(ctx, "rm", "-f", nameOrID)
+	return err
+}
+
+func (b *cliBackend) Logs(ctx context.Context, nameOrID string, opts LogsOptions) (*LogStream, error) {
+	args := []string{"logs"}
+	if opts.Follow {
+		args = append(args, "-f")
+	}
+	if opts.Tail > 0 {
+		args = append(args, "--tail", fmt.Sprint(opts.Tail))
+	}
+	if !opts.Since.IsZero() {
+		args = append(args, "--since", opts.Since.Format(time.RFC3339Nano))
+	}
+	args = append(args, nameOrID)
+
+	session, err := b.startSession(ctx, b.cmdBuilder.Docker(args...))
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("獲取標準輸出失敗: %w", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("獲取標準錯誤失敗: %w", err)
+	}
+
+	return &LogStream{
+		Stdout: stdout,
+		Stderr: stderr,
+		wait:   session.Wait,
+		close:  session.Close,
+	}, nil
+}
+
+func (b *cliBackend) Events(ctx context.Context, containerName string) (<-chan Event, <-chan error) {
+	eventCh := make(chan Event)
+	errCh := make(chan error, 1)
+
+	cmd := b.cmdBuilder.Docker("events", "--format", "'{{json .}}'",
+		"--filter", "type=container", "--filter", fmt.Sprintf("container=%s", containerName))
+
+	go func() {
+		defer close(eventCh)
+
+		session, err := b.startSession(ctx, cmd)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		defer session.Close()
+
+		stdout, err := session.StdoutPipe()
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if stderr, err := session.StderrPipe(); err == nil {
+			go drain(stderr)
+		}
+
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			var event Event
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+			select {
+			case eventCh <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if ctx.Err() == nil {
+			if err := session.Wait(); err != nil {
+				errCh <- fmt.Errorf("事件監聽中斷: %w", err)
+			}
+		}
+	}()
+
+	return eventCh, errCh
+}
+
+// runStreamed 以流式 session 執行可能需要拉取映像的命令，不受 timeouts.command 限制，只在 ctx 取消時中斷
+// 返回標準輸出；失敗時錯誤中附上標準錯誤的內容
+func (b *cliBackend) runStreamed(ctx context.Context, cmd string) (string, error) {
+	session, err := b.startSession(ctx, cmd)
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("獲取標準輸出失敗: %w", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return "", fmt.Errorf("獲取標準錯誤失敗: %w", err)
+	}
+
+	var errOutput bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&errOutput, stderr)
+		close(done)
+	}()
+	output, _ := io.ReadAll(stdout)
+	<-done
+
+	if err := session.Wait(); err != nil {
+		return string(output), fmt.Errorf("%w, output: %s", err, strings.TrimSpace(errOutput.String()))
+	}
+	return string(output), nil
+}
+
+// startSession 建立並啟動一個流式 session
+func (b *cliBackend) startSession(ctx context.Context, cmd string) (executor.Session, error) {
+	session, err := b.executor.CreateSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("創建 session 失敗: %w", err)
+	}
+	if err := session.Start(ctx, cmd); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("啟動命令失敗: %w", err)
+	}
+	return session, nil
+}
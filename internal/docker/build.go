@@ -0,0 +1,102 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// BuildSpec 在執行主機上以一次性建置容器編譯的配置
+type BuildSpec struct {
+	Image      string
+	Platform   string   // 例如 linux/arm64，與目標容器相同
+	Command    string   // 以 sh -c 執行
+	WorkingDir string   // 容器內執行命令的目錄
+	Env        []string // KEY=VALUE
+	Binds      []string // source:destination[:options]
+}
+
+// RunBuild 以一次性建置容器執行編譯命令，輸出逐行交給 output
+// 命令以非零狀態結束時返回錯誤；結束或取消後移除建置容器
+func (m *Manager) RunBuild(ctx context.Context, spec BuildSpec, output func(line string)) error {
+	name := m.config.GetDevContainerName() + "-build"
+
+	// 清除上次中斷時殘留的建置容器
+	if ids, err := m.backend.ListContainers(ctx, ListFilter{All: true, Name: name}); err == nil && len(ids) > 0 {
+		if err := m.backend.RemoveContainer(ctx, name); err != nil {
+			return fmt.Errorf("移除殘留的建置容器失敗: %w", err)
+		}
+	}
+
+	containerSpec := &ContainerSpec{
+		Name:       name,
+		Image:      spec.Image,
+		Platform:   spec.Platform,
+		Env:        spec.Env,
+		Binds:      spec.Binds,
+		WorkingDir: spec.WorkingDir,
+		Labels:     map[string]string{"dev-swap-build": "true"},
+		Cmd:        []string{"sh", "-c", spec.Command},
+	}
+	if _, err := m.backend.CreateContainer(ctx, containerSpec); err != nil {
+		// Engine API 不會自動拉取映像，拉取後再試一次
+		log.Printf("拉取建置映像 %s (%s)...", spec.Image, spec.Platform)
+		if pullErr := m.backend.PullImage(ctx, spec.Image, spec.Platform); pullErr != nil {
+			return fmt.Errorf("建立建置容器失敗: %w（拉取映像: %v）", err, pullErr)
+		}
+		if _, err := m.backend.CreateContainer(ctx, containerSpec); err != nil {
+			return fmt.Errorf("建立建置容器失敗: %w", err)
+		}
+	}
+	defer m.backend.RemoveContainer(context.WithoutCancel(ctx), name)
+
+	if err := m.backend.StartContainer(ctx, name); err != nil {
+		return fmt.Errorf("啟動建置容器失敗: %w", err)
+	}
+
+	// 容器結束後日誌流隨之結束
+	stream, err := m.backend.Logs(ctx, name, LogsOptions{Follow: true})
+	if err != nil {
+		return fmt.Errorf("讀取建置輸出失敗: %w", err)
+	}
+	defer stream.Close()
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, r := range []io.Reader{stream.Stdout, stream.Stderr} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				mu.Lock()
+				output(scanner.Text())
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := stream.Wait(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("讀取建置輸出失敗: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	info, err := m.backend.InspectContainer(ctx, name)
+	if err != nil {
+		return fmt.Errorf("讀取建置結果失敗: %w", err)
+	}
+	if info.State.Running {
+		return fmt.Errorf("建置輸出提前結束，建置容器仍在執行")
+	}
+	if info.State.ExitCode != 0 {
+		return fmt.Errorf("編譯失敗: 退出碼 %d", info.State.ExitCode)
+	}
+	return nil
+}
@@ -48,3 +48,8 @@ func (cb *CommandBuilder) DockerCompose(workDir string, args ...string) string {
 	
 	return cmd
 }
+
+// shellQuote 以單引號包裹參數，避免值中的特殊字元被 shell 解析
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
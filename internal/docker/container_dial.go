@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// PortTarget 返回轉發開發容器端口的 tunnel 目標
+// host 模式：原始容器已映射該端口時沿用原本的主機端口，否則使用開發容器新增的 loopback 映射
+// container 模式：每次連線時重新解析開發容器 IP（容器重建後 IP 可能改變）
+func (m *Manager) PortTarget(original *ContainerConfig, containerPort int, protocol string) executor.TunnelTarget {
+	if m.config.Host.TunnelTarget == config.TunnelTargetContainer {
+		devName := m.config.GetDevContainerName()
+		return executor.TunnelTarget{
+			Addr: fmt.Sprintf("%s:%d", devName, containerPort),
+			Dial: func(ctx context.Context) (net.Conn, error) {
+				return m.DialContainer(ctx, devName, containerPort)
+			},
+		}
+	}
+
+	if binding, ok := findPortBinding(original.PortBindings, fmt.Sprint(containerPort), protocol); ok {
+		host := binding.HostIP
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "127.0.0.1"
+		}
+		return executor.TunnelTarget{Addr: net.JoinHostPort(host, binding.HostPort)}
+	}
+	return executor.TunnelTarget{Addr: fmt.Sprintf("127.0.0.1:%d", containerPort)}
+}
+
+// ContainerIP 返回容器在其網路上的 IP（有多個網路時依網路名稱排序取第一個）
+func (m *Manager) ContainerIP(ctx context.Context, containerName string) (string, error) {
+	info, err := m.backend.InspectContainer(ctx, containerName)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(info.NetworkSettings.Networks))
+	for name := range info.NetworkSettings.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ip := info.NetworkSettings.Networks[name].IPAddress; ip != "" {
+			return ip, nil
+		}
+	}
+	return "", fmt.Errorf("容器 %s 沒有可用的 IP（容器可能未運行）", containerName)
+}
+
+// DialContainer 連接容器內的 TCP 端口
+// 遠端模式經由 SSH 直接連接容器 IP；本地模式（例如 Docker Desktop 無法路由容器 IP）透過 docker exec 橋接
+func (m *Manager) DialContainer(ctx context.Context, containerName string, port int) (net.Conn, error) {
+	if !m.executor.IsRemote() {
+		return m.dialContainerExec(ctx, containerName, "127.0.0.1", port)
+	}
+
+	ip, err := m.ContainerIP(ctx, containerName)
+	if err != nil {
+		return nil, err
+	}
+	return m.executor.Dial(ctx, "tcp", net.JoinHostPort(ip, fmt.Sprint(port)))
+}
+
+// bridgeScript 在容器內將標準輸入輸出橋接到 host:port，依序嘗試 socat 與 nc
+const bridgeScript = `if command -v socat >/dev/null 2>&1; then exec socat - TCP:%[1]s:%[2]d; ` +
+	`elif command -v nc >/dev/null 2>&1; then exec nc %[1]s %[2]d; ` +
+	`else echo "容器內找不到 socat 或 nc" >&2; exit 127; fi`
+
+// dialContainerExec 透過 docker exec 在容器內執行 socat / nc 連接 host:port，將其標準輸入輸出作為連線
+func (m *Manager) dialContainerExec(ctx context.Context, containerName, host string, port int) (net.Conn, error) {
+	session, err := m.executor.CreateSession(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("創建 session 失敗: %w", err)
+	}
+
+	cmd := m.cmdBuilder.Docker("exec", "-i", containerName, "sh", "-c", shellQuote(fmt.Sprintf(bridgeScript, host, port)))
+	if err := session.Start(ctx, cmd); err != nil {
+		session.Close()
+		return nil, fmt.Errorf("啟動橋接命令失敗: %w", err)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("獲取標準輸出失敗: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, fmt.Errorf("獲取標準輸入失敗: %w", err)
+	}
+	if stderr, err := session.StderrPipe(); err == nil {
+		go drain(stderr)
+	}
+
+	return &sessionConn{
+		session: session,
+		stdout:  stdout,
+		stdin:   stdin,
+		addr:    bridgeAddr(fmt.Sprintf("%s/%s:%d", containerName, host, port)),
+	}, nil
+}
+
+// sessionConn 將 session 的標準輸入輸出包裝為 net.Conn
+type sessionConn struct {
+	session executor.Session
+	stdout  io.Reader
+	stdin   io.WriteCloser
+	addr    net.Addr
+}
+
+func (c *sessionConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *sessionConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *sessionConn) Close() error {
+	c.stdin.Close()
+	return c.session.Close()
+}
+
+func (c *sessionConn) LocalAddr() net.Addr                { return c.addr }
+func (c *sessionConn) RemoteAddr() net.Addr               { return c.addr }
+func (c *sessionConn) SetDeadline(t time.Time) error      { return nil }
+func (c *sessionConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *sessionConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// bridgeAddr docker exec 橋接連線的地址（容器名稱:端口）
+type bridgeAddr string
+
+func (a bridgeAddr) Network() string { return "docker-exec" }
+func (a bridgeAddr) String() string  { return string(a) }
@@ -8,30 +8,27 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/laysdragon/go-docker-dev-swap/internal/config"
-	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
 )
 
 // LogFollower 容器日誌監控器
 type LogFollower struct {
-	executor      executor.Executor
 	manager       *Manager
-	cmdBuilder    *CommandBuilder
 	containerName string
 	logFile       *os.File
 	enableFile    bool
 	logFilePath   string
 	lineHandler   func(string)
+	lineMu        sync.Mutex
 }
 
 // NewLogFollower 創建日誌監控器
-func NewLogFollower(exec executor.Executor, manager *Manager, containerName string, rc *config.RuntimeConfig, handler func(string)) *LogFollower {
+func NewLogFollower(manager *Manager, containerName string, rc *config.RuntimeConfig, handler func(string)) *LogFollower {
 	return &LogFollower{
-		executor:      exec,
 		manager:       manager,
-		cmdBuilder:    NewCommandBuilder(rc),
 		containerName: containerName,
 		enableFile:    rc.Component.LogFile != nil && *rc.Component.LogFile != "",
 		logFilePath: func() string {
@@ -79,7 +76,7 @@ func (lf *LogFollower) Start(ctx context.Context) error {
 // followLogs 持續跟蹤容器日誌
 func (lf *LogFollower) followLogs(ctx context.Context) error {
 	// 使用 Manager 檢查容器是否運行
-	running, err := lf.manager.CheckContainerRunning(lf.containerName)
+	running, err := lf.manager.CheckContainerRunning(ctx, lf.containerName)
 	if err != nil {
 		return fmt.Errorf("檢查容器失敗: %w", err)
 	}
@@ -87,65 +84,64 @@ func (lf *LogFollower) followLogs(ctx context.Context) error {
 		return fmt.Errorf("容器 %s 不存在或未運行", lf.containerName)
 	}
 
-	// 使用 docker logs -f 持續跟蹤
-	// 使用 --tail 50 只顯示最近 50 行，避免歷史日誌過多
-	logsCmd := lf.cmdBuilder.Docker("logs", "-f", "--tail", "50", lf.containerName, "2>&1")
-
-	// 創建統一的 session（本地或遠端）
-	session, err := lf.executor.CreateSession()
+	// 持續跟蹤日誌，只顯示最近 50 行，避免歷史日誌過多
+	// stdout 與 stderr 由後端分別提供
+	stream, err := lf.manager.Logs(ctx, lf.containerName, LogsOptions{Follow: true, Tail: 50})
 	if err != nil {
-		return fmt.Errorf("創建 session 失敗: %w", err)
-	}
-	defer session.Close()
-
-	// 啟動命令（內部會自動處理 StdoutPipe 的獲取時機）
-	if err := session.Start(logsCmd); err != nil {
 		return fmt.Errorf("啟動日誌監控失敗: %w", err)
 	}
+	defer stream.Close()
 
-	// Start 之後獲取輸出流（此時兩種實現都已準備好）
-	stdout, err := session.StdoutPipe()
-	if err != nil {
-		return fmt.Errorf("獲取標準輸出失敗: %w", err)
-	}
-
-	return lf.streamLogs(ctx, stdout, session)
+	return lf.streamLogs(ctx, stream)
 }
 
-// streamLogs 統一處理日誌流
-func (lf *LogFollower) streamLogs(ctx context.Context, stdout io.Reader, session interface {
-	Close() error
-	Wait() error
-}) error {
-	// 創建一個 goroutine 來處理日誌輸出
-	errChan := make(chan error, 1)
-	go func() {
-		scanner := bufio.NewScanner(stdout)
-		for scanner.Scan() {
-			line := scanner.Text()
-			lf.processLogLine(line)
-		}
+// streamLogs 統一處理日誌流，同時讀取 stdout 與 stderr
+func (lf *LogFollower) streamLogs(ctx context.Context, stream *LogStream) error {
+	streams := []io.Reader{stream.Stdout, stream.Stderr}
+
+	// 每個輸出流各用一個 goroutine 讀取，避免其中一個寫滿阻塞命令
+	errChan := make(chan error, len(streams))
+	for _, output := range streams {
+		go func(r io.Reader) {
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				lf.processLogLine(scanner.Text())
+			}
 
-		if err := scanner.Err(); err != nil && err != io.EOF {
-			errChan <- err
-		} else {
-			errChan <- nil
+			if err := scanner.Err(); err != nil && err != io.EOF {
+				errChan <- err
+			} else {
+				errChan <- nil
+			}
+		}(output)
+	}
+
+	// 等待所有輸出流結束或上下文取消
+	var streamErr error
+	for range streams {
+		select {
+		case <-ctx.Done():
+			stream.Close() // 關閉日誌流
+			return ctx.Err()
+		case err := <-errChan:
+			if err != nil && streamErr == nil {
+				streamErr = err
+			}
 		}
-	}()
-
-	// 等待命令結束或上下文取消
-	select {
-	case <-ctx.Done():
-		session.Close() // 關閉 session
-		return ctx.Err()
-	case err := <-errChan:
-		session.Wait() // 等待命令完成
-		return err
 	}
+
+	if err := stream.Wait(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+	return streamErr
 }
 
 // processLogLine 處理單行日誌
 func (lf *LogFollower) processLogLine(line string) {
+	// stdout 與 stderr 由不同 goroutine 讀取，需保證單行輸出不交錯
+	lf.lineMu.Lock()
+	defer lf.lineMu.Unlock()
+
 	if lf.lineHandler != nil {
 		lf.lineHandler(line)
 	} else {
@@ -1,10 +1,16 @@
 package docker
 
 import (
-	"encoding/json"
+	"bufio"
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"net"
+	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/laysdragon/go-docker-dev-swap/internal/config"
 	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
@@ -14,10 +20,13 @@ type Manager struct {
 	executor   executor.Executor
 	config     *config.RuntimeConfig
 	cmdBuilder *CommandBuilder
+	backend    Backend
 }
 
 type ContainerConfig struct {
 	Name         string
+	ContainerID  string
+	Running      bool
 	Image        string
 	Env          []string
 	Volumes      []string
@@ -40,22 +49,39 @@ type DevContainer struct {
 	OriginalName string
 }
 
+// NewManager 建立容器管理器，依 host 的 docker_backend 選擇 CLI 或 Engine API 後端
+// docker compose 相關操作（compose ps / stop / start）與互動式 exec 一律使用 CLI
 func NewManager(exec executor.Executor, rc *config.RuntimeConfig) *Manager {
+	cmdBuilder := NewCommandBuilder(rc)
+
+	var backend Backend
+	if rc.Host.DockerBackend == config.DockerBackendAPI {
+		backend = newAPIBackend(exec, rc.Host.DockerSocket, rc.Host.Timeouts.Command)
+	} else {
+		backend = newCLIBackend(exec, cmdBuilder)
+	}
+
 	return &Manager{
 		executor:   exec,
 		config:     rc,
-		cmdBuilder: NewCommandBuilder(rc),
+		cmdBuilder: cmdBuilder,
+		backend:    backend,
 	}
 }
 
-func (m *Manager) GetContainerConfig(serviceName string) (*ContainerConfig, error) {
+// Backend 返回目前使用的容器操作後端
+func (m *Manager) Backend() Backend {
+	return m.backend
+}
+
+func (m *Manager) GetContainerConfig(ctx context.Context, serviceName string) (*ContainerConfig, error) {
 	var inspectTarget string
 
 	if m.config.Project.Type == config.ProjectTypeContainer {
 		inspectTarget = serviceName
 	} else {
 		cmd := m.cmdBuilder.DockerCompose(m.config.Project.ComposeDir, "ps", "-q", serviceName, "-a")
-		containerID, err := m.executor.Execute(cmd)
+		containerID, err := m.executor.Execute(ctx, cmd)
 		if err != nil {
 			return nil, fmt.Errorf("獲取容器 ID 失敗: %w", err)
 		}
@@ -67,148 +93,88 @@ func (m *Manager) GetContainerConfig(serviceName string) (*ContainerConfig, erro
 	}
 
 	// 獲取容器詳細資訊
-	cmd := m.cmdBuilder.Docker("inspect", inspectTarget)
-	output, err := m.executor.Execute(cmd)
+	info, err := m.backend.InspectContainer(ctx, inspectTarget)
 	if err != nil {
 		return nil, fmt.Errorf("獲取容器資訊失敗: %w", err)
 	}
 
-	var inspectData []map[string]interface{}
-	if err := json.Unmarshal([]byte(output), &inspectData); err != nil {
-		return nil, fmt.Errorf("解析容器資訊失敗: %w", err)
-	}
-
-	if len(inspectData) == 0 {
-		return nil, fmt.Errorf("找不到容器")
-	}
-
-	data := inspectData[0]
 	cfg := &ContainerConfig{
-		Name:   serviceName,
-		Labels: make(map[string]string),
+		Name:        serviceName,
+		ContainerID: info.ID,
+		Running:     info.State.Running,
+		Image:       info.Config.Image,
+		Env:         info.Config.Env,
+		Command:     strings.Join(info.Config.Cmd, " "),
+		WorkingDir:  info.Config.WorkingDir,
+		Labels:      make(map[string]string),
 	}
-
-	// 解析配置
-	if configData, ok := data["Config"].(map[string]interface{}); ok {
-		if image, ok := configData["Image"].(string); ok {
-			cfg.Image = image
-		}
-		if env, ok := configData["Env"].([]interface{}); ok {
-			for _, e := range env {
-				if envStr, ok := e.(string); ok {
-					cfg.Env = append(cfg.Env, envStr)
-				}
-			}
-		}
-		if cmd, ok := configData["Cmd"].([]interface{}); ok {
-			cmdStrs := make([]string, len(cmd))
-			for i, c := range cmd {
-				cmdStrs[i] = fmt.Sprint(c)
-			}
-			cfg.Command = strings.Join(cmdStrs, " ")
-		}
-		if wd, ok := configData["WorkingDir"].(string); ok {
-			cfg.WorkingDir = wd
-		}
-		// 解析 Labels
-		if labels, ok := configData["Labels"].(map[string]interface{}); ok {
-			for k, v := range labels {
-				if vStr, ok := v.(string); ok {
-					cfg.Labels[k] = vStr
-				}
-			}
-		}
+	for k, v := range info.Config.Labels {
+		cfg.Labels[k] = v
 	}
 
 	// 解析掛載
-	if mounts, ok := data["Mounts"].([]interface{}); ok {
-		for _, m := range mounts {
-			if mount, ok := m.(map[string]interface{}); ok {
-				source := mount["Source"].(string)
-				dest := mount["Destination"].(string)
-				cfg.Volumes = append(cfg.Volumes, fmt.Sprintf("%s:%s", source, dest))
-			}
-		}
+	for _, mount := range info.Mounts {
+		cfg.Volumes = append(cfg.Volumes, fmt.Sprintf("%s:%s", mount.Source, mount.Destination))
 	}
 
 	// 解析網路
-	if networkSettings, ok := data["NetworkSettings"].(map[string]interface{}); ok {
-		if networks, ok := networkSettings["Networks"].(map[string]interface{}); ok {
-			for name := range networks {
-				cfg.Networks = append(cfg.Networks, name)
-			}
-		}
+	for name := range info.NetworkSettings.Networks {
+		cfg.Networks = append(cfg.Networks, name)
 	}
 
 	// 解析 PortBindings (HostConfig)
-	if hostConfig, ok := data["HostConfig"].(map[string]interface{}); ok {
-		if bindings, ok := hostConfig["PortBindings"].(map[string]interface{}); ok {
-			for key, bindingValue := range bindings {
-				containerPort, protocol := parsePortKey(key)
-				entries, _ := bindingValue.([]interface{})
-				for _, entry := range entries {
-					bindingMap, ok := entry.(map[string]interface{})
-					if !ok {
-						continue
-					}
-					hostPort, _ := bindingMap["HostPort"].(string)
-					if hostPort == "" {
-						continue
-					}
-					cfg.PortBindings = append(cfg.PortBindings, PortBinding{
-						HostIP:        asString(bindingMap["HostIp"]),
-						HostPort:      hostPort,
-						ContainerPort: containerPort,
-						Protocol:      protocol,
-					})
-				}
+	for key, bindings := range info.HostConfig.PortBindings {
+		containerPort, protocol := parsePortKey(key)
+		for _, binding := range bindings {
+			if binding.HostPort == "" {
+				continue
 			}
+			cfg.PortBindings = append(cfg.PortBindings, PortBinding{
+				HostIP:        binding.HostIP,
+				HostPort:      binding.HostPort,
+				ContainerPort: containerPort,
+				Protocol:      protocol,
+			})
 		}
 	}
 
 	return cfg, nil
 }
 
-func (m *Manager) StopContainer(serviceName string) error {
+func (m *Manager) StopContainer(ctx context.Context, serviceName string) error {
 	if m.config.Project.Type == config.ProjectTypeContainer {
-		cmd := m.cmdBuilder.Docker("stop", serviceName)
-		_, err := m.executor.Execute(cmd)
-		return err
+		return m.backend.StopContainer(ctx, serviceName)
 	}
 
 	cmd := m.cmdBuilder.DockerCompose(m.config.Project.ComposeDir, "stop", serviceName)
-	_, err := m.executor.Execute(cmd)
+	_, err := m.executor.Execute(ctx, cmd)
 	return err
 }
 
 // CheckDevContainerExists 檢查開發容器是否已存在並驗證是否為本工具創建的
-func (m *Manager) CheckDevContainerExists(devName string) (exists bool, isDevSwap bool, containerID string, err error) {
+func (m *Manager) CheckDevContainerExists(ctx context.Context, devName string) (exists bool, isDevSwap bool, containerID string, err error) {
 	// 檢查容器是否存在
-	cmd := m.cmdBuilder.Docker("ps", "-a", fmt.Sprintf("--filter name=^/%s$", devName), "--format '{{.ID}}'")
-	output, err := m.executor.Execute(cmd)
+	ids, err := m.backend.ListContainers(ctx, ListFilter{All: true, Name: devName})
 	if err != nil {
 		return false, false, "", fmt.Errorf("檢查容器失敗: %w", err)
 	}
-
-	containerID = strings.TrimSpace(output)
-	if containerID == "" {
+	if len(ids) == 0 {
 		return false, false, "", nil
 	}
+	containerID = ids[0]
 
 	// 容器存在，檢查是否有 dev-swap=true 標籤
-	cmd = m.cmdBuilder.Docker("inspect", containerID, "--format '{{index .Config.Labels \"dev-swap\"}}'")
-	output, err = m.executor.Execute(cmd)
+	info, err := m.backend.InspectContainer(ctx, containerID)
 	if err != nil {
 		return true, false, containerID, fmt.Errorf("檢查容器標籤失敗: %w", err)
 	}
 
-	label := strings.TrimSpace(output)
-	return true, label == "true", containerID, nil
+	return true, info.Config.Labels["dev-swap"] == "true", containerID, nil
 }
 
 // RemoveDevContainerIfExists 移除已存在的開發容器（如果存在且為本工具創建）
-func (m *Manager) RemoveDevContainerIfExists(devName string) error {
-	exists, isDevSwap, containerID, err := m.CheckDevContainerExists(devName)
+func (m *Manager) RemoveDevContainerIfExists(ctx context.Context, devName string) error {
+	exists, isDevSwap, containerID, err := m.CheckDevContainerExists(ctx, devName)
 	if err != nil {
 		return err
 	}
@@ -222,20 +188,18 @@ func (m *Manager) RemoveDevContainerIfExists(devName string) error {
 	}
 
 	// 移除容器
-	cmd := m.cmdBuilder.Docker("rm", "-f", containerID)
-	_, err = m.executor.Execute(cmd)
-	if err != nil {
+	if err := m.backend.RemoveContainer(ctx, containerID); err != nil {
 		return fmt.Errorf("移除殘留容器失敗: %w", err)
 	}
 
 	return nil
 }
 
-func (m *Manager) CreateDevContainer(original *ContainerConfig, remoteDlvPath string) (*DevContainer, error) {
+func (m *Manager) CreateDevContainer(ctx context.Context, original *ContainerConfig, remoteDlvPath string) (*DevContainer, error) {
 	devName := m.config.GetDevContainerName()
 
 	// 檢查是否有殘留的開發容器
-	exists, isDevSwap, containerID, err := m.CheckDevContainerExists(devName)
+	exists, isDevSwap, containerID, err := m.CheckDevContainerExists(ctx, devName)
 	if err != nil {
 		return nil, fmt.Errorf("檢查容器狀態失敗: %w", err)
 	}
@@ -248,62 +212,74 @@ func (m *Manager) CreateDevContainer(original *ContainerConfig, remoteDlvPath st
 		}
 	}
 
-	// 構建 docker run 命令
-	var cmdParts []string
-	cmdParts = append(cmdParts, "run -d")
-	cmdParts = append(cmdParts, fmt.Sprintf("--name %s", devName))
-
-	// 環境變數
-	for _, env := range original.Env {
-		cmdParts = append(cmdParts, fmt.Sprintf("-e '%s'", env))
+	spec := &ContainerSpec{
+		Name:       devName,
+		Image:      original.Image,
+		Env:        original.Env,
+		Networks:   original.Networks,
+		WorkingDir: original.WorkingDir,
+		Labels:     make(map[string]string),
+		Cmd:        []string{"sh", "/app/init.sh"},
 	}
 
-	// 原始掛載
-	for _, vol := range original.Volumes {
-		cmdParts = append(cmdParts, fmt.Sprintf("-v %s", vol))
-	}
+	// 反向轉發：讓開發容器經由 host gateway 連回本地服務
+	spec.Env = append(append([]string(nil), original.Env...), m.reverseForwardEnv()...)
+	spec.ExtraHosts = m.reverseForwardHosts()
 
-	// 新增執行檔掛載
-	cmdParts = append(cmdParts, fmt.Sprintf("-v %s:%s",
-		m.config.GetRemoteBinaryPath(), m.config.Component.ContainerBinaryPath))
+	// 原始掛載
+	spec.Binds = append(spec.Binds, original.Volumes...)
 
-	cmdParts = append(cmdParts, fmt.Sprintf("-v %s:/app/entry.sh", m.config.GetRemoteEntryScriptPath()))
-	cmdParts = append(cmdParts, fmt.Sprintf("-v %s:/app/init.sh", m.config.GetRemoteInitScriptPath()))
+	// 新增執行檔（sync 類型為同步目錄）與腳本掛載
+	// 執行檔所在的遠端工作目錄以目錄掛載，supervisor 直接執行其中的執行檔，重新部署時不需要重啟容器
+	if sync := m.config.Component.Sync; m.config.Component.Kind == config.ComponentKindSync {
+		spec.Binds = append(spec.Binds, fmt.Sprintf("%s:%s", m.config.GetRemoteSyncPath(), sync.ContainerDir))
+	} else {
+		spec.Binds = append(spec.Binds, fmt.Sprintf("%s:%s:ro", m.config.Host.RemoteWorkDir, stageDir))
+	}
+	spec.Binds = append(spec.Binds,
+		fmt.Sprintf("%s:%s", m.config.GetRemoteEntryScriptPath(), entryScriptPath),
+		fmt.Sprintf("%s:/app/init.sh", m.config.GetRemoteInitScriptPath()),
+	)
+
+	// artifacts：目錄直接掛載到 container_path，容器內可即時看到同步的變更
+	// 單一檔案上傳時會換成新的檔案，檔案掛載仍指向舊檔案，因此改放在 stageDir 的目錄掛載中，由 supervisor 連結到 container_path
+	var prepare []string
+	fileArtifacts := false
+	for _, art := range m.config.Component.Artifacts {
+		if art.IsFile() {
+			prepare = append(prepare, linkCommand(true, m.config.GetStagedArtifactFilePath(art), art.ContainerPath))
+			fileArtifacts = true
+			continue
+		}
+		spec.Binds = append(spec.Binds, fmt.Sprintf("%s:%s", m.config.GetRemoteArtifactPath(art.Name), art.ContainerPath))
+	}
+	// kind: sync 不掛載遠端工作目錄，另外掛載 artifacts 目錄
+	if fileArtifacts && m.config.Component.Kind == config.ComponentKindSync {
+		spec.Binds = append(spec.Binds, fmt.Sprintf("%s:%s:ro", m.config.GetRemoteArtifactsDir(), path.Join(stageDir, "artifacts")))
+	}
 
 	// 如果有 dlv，也掛載進去
 	if remoteDlvPath != "" {
-		cmdParts = append(cmdParts, fmt.Sprintf("-v %s:%s/dlv", remoteDlvPath, original.WorkingDir))
+		spec.Binds = append(spec.Binds, fmt.Sprintf("%s:%s/dlv", remoteDlvPath, original.WorkingDir))
 	}
 
 	// 端口映射
-	for _, binding := range original.PortBindings {
-		hostSpec := binding.HostPort
-		if binding.HostIP != "" && binding.HostIP != "0.0.0.0" {
-			hostSpec = fmt.Sprintf("%s:%s", binding.HostIP, binding.HostPort)
-		}
-		containerSpec := binding.ContainerPort
-		if binding.Protocol != "" && binding.Protocol != "tcp" {
-			containerSpec = fmt.Sprintf("%s/%s", binding.ContainerPort, binding.Protocol)
-		}
-		cmdParts = append(cmdParts, fmt.Sprintf("-p %s:%s", hostSpec, containerSpec))
-	}
+	spec.PortBindings = append(spec.PortBindings, original.PortBindings...)
 
-	if m.config.Component.DlvConfig != nil && m.config.Component.DlvConfig.Enabled {
-		cmdParts = append(cmdParts, fmt.Sprintf("-p %d:%d",
-			m.config.Component.DlvConfig.Port, m.config.Component.DlvConfig.Port))
+	for _, port := range m.config.Component.ExtraPorts {
+		spec.PortBindings = addPortBinding(spec.PortBindings, PortBinding{HostPort: fmt.Sprint(port), ContainerPort: fmt.Sprint(port), Protocol: "tcp"})
 	}
-	//for _, port := range m.config.Component.ExtraPorts {
-	//	cmdParts = append(cmdParts, fmt.Sprintf("-p %d:%d", port, port))
-	//}
-
-	// 網路
-	for _, network := range original.Networks {
-		cmdParts = append(cmdParts, fmt.Sprintf("--network %s", network))
-	}
-
-	// Working Directory
-	if original.WorkingDir != "" {
-		cmdParts = append(cmdParts, fmt.Sprintf("-w %s", original.WorkingDir))
+	// container 模式下 tunnel 直接連接容器 IP，debugger 與轉發端口都不需要在主機上發布
+	if m.config.Host.TunnelTarget != config.TunnelTargetContainer {
+		if m.config.Component.DlvConfig != nil && m.config.Component.DlvConfig.Enabled {
+			port := fmt.Sprint(m.config.Component.DlvConfig.Port)
+			spec.PortBindings = addPortBinding(spec.PortBindings, PortBinding{HostPort: port, ContainerPort: port, Protocol: "tcp"})
+		}
+		// 轉發的端口只需要讓 tunnel 連得到，映射到主機的 loopback 即可
+		for _, fwd := range m.config.Component.Forwards {
+			port := fmt.Sprint(fwd.ContainerPort)
+			spec.PortBindings = addPortBinding(spec.PortBindings, PortBinding{HostIP: "127.0.0.1", HostPort: port, ContainerPort: port, Protocol: fwd.Protocol})
+		}
 	}
 
 	// 繼承原始容器的標籤
@@ -312,38 +288,53 @@ func (m *Manager) CreateDevContainer(original *ContainerConfig, remoteDlvPath st
 		if strings.HasPrefix(k, "com.docker.compose") {
 			continue
 		}
-		cmdParts = append(cmdParts, fmt.Sprintf("-l '%s=%s'", k, v))
+		spec.Labels[k] = v
 	}
 
 	// 添加開發容器標籤
-	cmdParts = append(cmdParts, "-l dev-swap=true")
-
-	// 映像
-	cmdParts = append(cmdParts, original.Image)
-
-	var entryParts []string
-	// 命令 (使用 dlv 或直接執行)
-	if m.config.Component.DlvConfig != nil && m.config.Component.DlvConfig.Enabled {
-		// 需要 continue 不然需要連線兩次應用才會正式開始執行，原因不明
-		dlvCmd := fmt.Sprintf("./dlv exec %s --headless --listen=:%d --api-version=2 --accept-multiclient --continue %s",
-			m.config.Component.ContainerBinaryPath, m.config.Component.DlvConfig.Port, m.config.Component.DlvConfig.Args)
-		entryParts = append(entryParts, dlvCmd)
-		//entryParts = append(entryParts, fmt.Sprintf("sh -c '%s'", dlvCmd))
+	spec.Labels["dev-swap"] = "true"
+
+	// 命令 (sync 類型執行啟動命令，否則使用 dlv 或直接執行)，由 supervisor 啟動與重新啟動
+	supervisor := supervisorOptions{StopSignal: "TERM", Prepare: prepare}
+	if sync := m.config.Component.Sync; m.config.Component.Kind == config.ComponentKindSync {
+		command := sync.Command
+		if command == "" {
+			command = original.Command
+		}
+		if command == "" {
+			return nil, fmt.Errorf("sync.command 未設定，且無法取得原始容器的命令")
+		}
+		supervisor.Command = fmt.Sprintf("cd %s && exec %s", shellQuote(sync.ContainerDir), command)
 	} else {
-		entryParts = append(entryParts, m.config.Component.ContainerBinaryPath)
+		// 直接執行目錄掛載中的執行檔；container_binary_path 的連結只為了相容依賴原路徑的腳本，
+		// 非 root 使用者或唯讀根檔案系統無法建立時只輸出警告
+		binaryPath := m.config.GetStagedBinaryPath()
+		supervisor.Prepare = append(supervisor.Prepare, linkCommand(false, binaryPath, m.config.Component.ContainerBinaryPath))
+
+		if dlv := m.config.Component.DlvConfig; dlv != nil && dlv.Enabled {
+			// dlv 收到 INT 時會結束它啟動的目標程式
+			supervisor.StopSignal = "INT"
+			if dlv.Mode == config.DlvModeDAP {
+				// DAP 模式：由編輯器透過 launch 請求啟動目標程式（program 為目錄掛載中的執行檔）
+				// dlv dap 在編輯器中斷連線後即結束，重新啟動以便再次連接
+				supervisor.Command = fmt.Sprintf("exec ./dlv dap --listen=:%d %s", dlv.Port, dlv.Args)
+				supervisor.RelaunchOnExit = true
+			} else {
+				// 不使用 --continue：目標程式以暫停狀態啟動，由工具恢復斷點後透過 JSON-RPC 繼續執行
+				supervisor.Command = fmt.Sprintf("exec ./dlv exec %s --headless --listen=:%d --api-version=2 --accept-multiclient %s",
+					shellQuote(binaryPath), dlv.Port, dlv.Args)
+			}
+		} else {
+			supervisor.Command = "exec " + shellQuote(binaryPath)
+		}
 	}
-	cmdParts = append(cmdParts, "sh /app/init.sh")
 
-	if err := m.executor.CreateScript(strings.Join(entryParts, " "), m.config.GetRemoteEntryScriptPath()); err != nil {
+	if err := m.executor.CreateScript(ctx, supervisorScript(supervisor), m.config.GetRemoteEntryScriptPath()); err != nil {
 		return nil, fmt.Errorf("上傳入口腳本失敗: %w", err)
 	}
 
-	// 使用 CommandBuilder 構建完整的 docker 命令
-	cmd := m.cmdBuilder.Docker(cmdParts...)
-	log.Printf("執行命令: %s", cmd)
-	output, err := m.executor.Execute(cmd)
-	if err != nil {
-		return nil, fmt.Errorf("建立開發容器失敗: %w, output: %s", err, output)
+	if _, err := m.backend.CreateContainer(ctx, spec); err != nil {
+		return nil, fmt.Errorf("建立開發容器失敗: %w", err)
 	}
 
 	return &DevContainer{
@@ -352,46 +343,216 @@ func (m *Manager) CreateDevContainer(original *ContainerConfig, remoteDlvPath st
 	}, nil
 }
 
-func (m *Manager) StartContainer(name string) error {
-	cmd := m.cmdBuilder.Docker("start", name)
-	_, err := m.executor.Execute(cmd)
-	return err
+func (m *Manager) StartContainer(ctx context.Context, name string) error {
+	return m.backend.StartContainer(ctx, name)
 }
 
-func (m *Manager) RestartContainer(name string) error {
-	cmd := m.cmdBuilder.Docker("restart", name)
-	_, err := m.executor.Execute(cmd)
-	return err
+func (m *Manager) RestartContainer(ctx context.Context, name string) error {
+	return m.backend.RestartContainer(ctx, name)
 }
 
-func (m *Manager) RemoveDevContainer(name string) error {
-	cmd := m.cmdBuilder.Docker("rm", "-f", name)
-	_, err := m.executor.Execute(cmd)
-	return err
+// SignalContainer 向容器的主程序送出信號
+func (m *Manager) SignalContainer(ctx context.Context, name, signal string) error {
+	return m.backend.SignalContainer(ctx, name, signal)
 }
 
-func (m *Manager) RestoreOriginalContainer(serviceName string) error {
+func (m *Manager) RemoveDevContainer(ctx context.Context, name string) error {
+	return m.backend.RemoveContainer(ctx, name)
+}
+
+func (m *Manager) RestoreOriginalContainer(ctx context.Context, serviceName string) error {
 	if m.config.Project.Type == config.ProjectTypeContainer {
-		cmd := m.cmdBuilder.Docker("start", serviceName)
-		_, err := m.executor.Execute(cmd)
-		return err
+		return m.backend.StartContainer(ctx, serviceName)
 	}
 
 	cmd := m.cmdBuilder.DockerCompose(m.config.Project.ComposeDir, "start", serviceName)
-	_, err := m.executor.Execute(cmd)
+	_, err := m.executor.Execute(ctx, cmd)
 	return err
 }
 
 // CheckContainerRunning 檢查容器是否正在運行
-func (m *Manager) CheckContainerRunning(containerName string) (bool, error) {
-	cmd := m.cmdBuilder.Docker("ps", "-q", fmt.Sprintf("--filter name=^/%s$", containerName))
-	output, err := m.executor.Execute(cmd)
+func (m *Manager) CheckContainerRunning(ctx context.Context, containerName string) (bool, error) {
+	ids, err := m.backend.ListContainers(ctx, ListFilter{Name: containerName})
 	if err != nil {
 		return false, fmt.Errorf("檢查容器運行狀態失敗: %w", err)
 	}
+	return len(ids) > 0, nil
+}
 
-	containerID := strings.TrimSpace(output)
-	return containerID != "", nil
+// Logs 讀取容器日誌
+func (m *Manager) Logs(ctx context.Context, containerName string, opts LogsOptions) (*LogStream, error) {
+	return m.backend.Logs(ctx, containerName, opts)
+}
+
+// CurrentRunLogsContain 檢查容器本次啟動後的日誌（stdout 與 stderr）是否包含 substr
+// 重啟後 docker logs 仍保留先前的輸出，因此以容器的啟動時間（主機時鐘）過濾；
+// since 晚於啟動時間時（supervisor 在容器內重新啟動程式）改以 since 過濾
+func (m *Manager) CurrentRunLogsContain(ctx context.Context, containerName, substr string, since time.Time) (bool, error) {
+	info, err := m.backend.InspectContainer(ctx, containerName)
+	if err != nil {
+		return false, err
+	}
+	opts := LogsOptions{}
+	if startedAt, err := time.Parse(time.RFC3339Nano, info.State.StartedAt); err == nil {
+		opts.Since = startedAt
+	}
+	if since.After(opts.Since) {
+		opts.Since = since
+	}
+
+	stream, err := m.backend.Logs(ctx, containerName, opts)
+	if err != nil {
+		return false, err
+	}
+	defer stream.Close()
+
+	var (
+		wg    sync.WaitGroup
+		found atomic.Bool
+	)
+	for _, r := range []io.Reader{stream.Stdout, stream.Stderr} {
+		if r == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				if strings.Contains(scanner.Text(), substr) {
+					found.Store(true)
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	if err := stream.Wait(); err != nil && !found.Load() {
+		return false, fmt.Errorf("讀取容器日誌失敗: %w", err)
+	}
+	return found.Load(), nil
+}
+
+// Events 訂閱容器事件
+func (m *Manager) Events(ctx context.Context, containerName string) (<-chan Event, <-chan error) {
+	return m.backend.Events(ctx, containerName)
+}
+
+// shellCandidates 開啟互動式 shell 時依序嘗試的 shell
+var shellCandidates = []string{"bash", "sh", "ash"}
+
+// DetectShell 依序嘗試 bash / sh / ash，返回容器內第一個可用的 shell
+func (m *Manager) DetectShell(ctx context.Context, containerName string) (string, error) {
+	for _, shell := range shellCandidates {
+		cmd := m.cmdBuilder.Docker("exec", containerName, shell, "-c", "'exit 0'")
+		if _, err := m.executor.Execute(ctx, cmd); err == nil {
+			return shell, nil
+		} else if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+	}
+	return "", fmt.Errorf("容器 %s 內找不到可用的 shell (%s)", containerName, strings.Join(shellCandidates, "/"))
+}
+
+// ShellCommand 構建進入容器的互動式 shell 命令（需搭配 PTY session 使用）
+func (m *Manager) ShellCommand(containerName, shell, term string) string {
+	args := []string{"exec", "-it"}
+	if term != "" {
+		args = append(args, "-e", fmt.Sprintf("TERM=%s", term))
+	}
+	args = append(args, containerName, shell)
+	return m.cmdBuilder.Docker(args...)
+}
+
+// reverseForwardPort 返回開發容器連回反向轉發時使用的主機端口
+// 遠端模式連接 SSH 在遠端主機上監聽的端口；本地模式直接連接本地服務
+func (m *Manager) reverseForwardPort(rev config.ReverseForward) int {
+	if m.executor.IsRemote() {
+		return rev.RemotePort
+	}
+	return rev.LocalPort
+}
+
+// reverseForwardEnv 返回反向轉發注入開發容器的環境變數
+func (m *Manager) reverseForwardEnv() []string {
+	var env []string
+	for _, rev := range m.config.Component.ReverseForwards {
+		if rev.Env != "" {
+			env = append(env, fmt.Sprintf("%s=%s:%d", rev.Env, rev.ContainerHost(), m.reverseForwardPort(rev)))
+		}
+	}
+	return env
+}
+
+// reverseForwardHosts 返回反向轉發注入開發容器的 hosts 項目，皆指向 host gateway
+func (m *Manager) reverseForwardHosts() []string {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, rev := range m.config.Component.ReverseForwards {
+		if !rev.Injected() || seen[rev.ContainerHost()] {
+			continue
+		}
+		seen[rev.ContainerHost()] = true
+		hosts = append(hosts, fmt.Sprintf("%s:host-gateway", rev.ContainerHost()))
+	}
+	return hosts
+}
+
+// BridgeGateway 返回執行主機上預設 bridge 網路的 gateway IPv4 地址，即容器內 host-gateway 解析到的地址
+// 注入容器的反向轉發只在此地址監聽，不對外開放
+func (m *Manager) BridgeGateway(ctx context.Context) (string, error) {
+	cmd := m.cmdBuilder.Docker("network", "inspect", "bridge", "--format", shellQuote("{{range .IPAM.Config}}{{.Gateway}} {{end}}"))
+	output, err := m.executor.Execute(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("讀取 bridge 網路 gateway 失敗: %w", err)
+	}
+	for _, field := range strings.Fields(output) {
+		if ip := net.ParseIP(field); ip != nil && ip.To4() != nil {
+			return field, nil
+		}
+	}
+	return "", fmt.Errorf("bridge 網路沒有 IPv4 gateway")
+}
+
+// addPortBinding 加入端口映射，容器端口已映射到固定主機端口時略過
+func addPortBinding(bindings []PortBinding, binding PortBinding) []PortBinding {
+	if _, ok := findPortBinding(bindings, binding.ContainerPort, binding.Protocol); ok {
+		return bindings
+	}
+	return append(bindings, binding)
+}
+
+// findPortBinding 查找容器端口對應的固定主機端口映射
+func findPortBinding(bindings []PortBinding, containerPort, protocol string) (PortBinding, bool) {
+	key := PortBinding{ContainerPort: containerPort, Protocol: protocol}.portKey()
+	for _, binding := range bindings {
+		if binding.portKey() == key && binding.HostPort != "" {
+			return binding, true
+		}
+	}
+	return PortBinding{}, false
+}
+
+// publishSpec 返回 docker run -p 使用的端口映射格式
+func (b PortBinding) publishSpec() string {
+	hostSpec := b.HostPort
+	if b.HostIP != "" && b.HostIP != "0.0.0.0" {
+		hostSpec = fmt.Sprintf("%s:%s", b.HostIP, b.HostPort)
+	}
+	containerSpec := b.ContainerPort
+	if b.Protocol != "" && b.Protocol != "tcp" {
+		containerSpec = fmt.Sprintf("%s/%s", b.ContainerPort, b.Protocol)
+	}
+	return fmt.Sprintf("%s:%s", hostSpec, containerSpec)
+}
+
+// portKey 返回 Engine API 使用的端口鍵值，例如 "2345/tcp"
+func (b PortBinding) portKey() string {
+	protocol := b.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	return fmt.Sprintf("%s/%s", b.ContainerPort, protocol)
 }
 
 func parsePortKey(key string) (containerPort string, protocol string) {
@@ -404,9 +565,7 @@ func parsePortKey(key string) (containerPort string, protocol string) {
 	return
 }
 
-func asString(value interface{}) string {
-	if s, ok := value.(string); ok {
-		return s
-	}
-	return ""
+// drain 讀取並丟棄剩餘輸出，避免管道寫滿阻塞命令
+func drain(r io.Reader) {
+	io.Copy(io.Discard, r)
 }
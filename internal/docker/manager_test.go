@@ -0,0 +1,210 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// newTestManager 建立以 RecordingExecutor 驅動的 Manager，唯讀查詢只由 fixture 回答
+func newTestManager(fixture *executor.Fixture) (*Manager, *executor.RecordingExecutor) {
+	rc := &config.RuntimeConfig{
+		ComponentKey:         "api",
+		Mode:                 "remote",
+		DockerCommand:        "docker",
+		DockerComposeCommand: "docker compose",
+		Component: config.Component{
+			Kind:                config.ComponentKindBinary,
+			TargetService:       "api",
+			ContainerBinaryPath: "/app/api",
+			DlvConfig:           &config.DlvConfig{Enabled: true, Port: 2345, Mode: config.DlvModeAPI},
+		},
+		Host: config.Host{
+			RemoteWorkDir:    "/tmp/dev",
+			RemoteBinaryName: "service",
+			DockerBackend:    config.DockerBackendCLI,
+		},
+		Project: config.Project{Type: config.ProjectTypeCompose, ComposeDir: "/opt/app"},
+	}
+	rec := executor.NewRecordingExecutor(nil, fixture, rc)
+	return NewManager(rec, rc), rec
+}
+
+const apiInspect = `[{
+	"Id": "abc123",
+	"Name": "/app-api-1",
+	"State": {"Status": "running", "Running": true},
+	"Config": {
+		"Image": "app:1",
+		"Env": ["PORT=8080"],
+		"Cmd": ["/app/api"],
+		"WorkingDir": "/app",
+		"Labels": {"com.docker.compose.service": "api", "team": "core"}
+	},
+	"HostConfig": {"PortBindings": {"8080/tcp": [{"HostIp": "", "HostPort": "8080"}]}},
+	"Mounts": [{"Type": "bind", "Source": "/srv/data", "Destination": "/data"}],
+	"NetworkSettings": {"Networks": {"app_default": {"NetworkID": "n1"}}}
+}]`
+
+func TestGetContainerConfigFromFixture(t *testing.T) {
+	fixture := executor.NewFixture()
+	fixture.Add("cd /opt/app && docker compose ps -q api -a", "abc123\n", nil)
+	fixture.Add("docker inspect --type container abc123", apiInspect, nil)
+	m, rec := newTestManager(fixture)
+
+	cfg, err := m.GetContainerConfig(context.Background(), "api")
+	if err != nil {
+		t.Fatalf("GetContainerConfig: %v", err)
+	}
+	if cfg.ContainerID != "abc123" || cfg.Image != "app:1" || !cfg.Running {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if len(cfg.Volumes) != 1 || cfg.Volumes[0] != "/srv/data:/data" {
+		t.Errorf("volumes = %v", cfg.Volumes)
+	}
+	if len(cfg.Networks) != 1 || cfg.Networks[0] != "app_default" {
+		t.Errorf("networks = %v", cfg.Networks)
+	}
+	if len(cfg.PortBindings) != 1 || cfg.PortBindings[0].HostPort != "8080" || cfg.PortBindings[0].Protocol != "tcp" {
+		t.Errorf("port bindings = %+v", cfg.PortBindings)
+	}
+	if steps := rec.Steps(); len(steps) != 0 {
+		t.Errorf("read-only queries must not be recorded as changes, got %v", steps)
+	}
+}
+
+func TestStopContainerIsRecorded(t *testing.T) {
+	m, rec := newTestManager(executor.NewFixture())
+
+	if err := m.StopContainer(context.Background(), "api"); err != nil {
+		t.Fatalf("StopContainer: %v", err)
+	}
+	steps := rec.Steps()
+	if len(steps) != 1 || steps[0].Kind != executor.PlanStepCommand || steps[0].Detail != "cd /opt/app && docker compose stop api" {
+		t.Errorf("steps = %+v", steps)
+	}
+}
+
+func TestCreateDevContainerPlan(t *testing.T) {
+	fixture := executor.NewFixture()
+	fixture.Add("docker ps -q -a --filter name=^/api-dev$", "", nil)
+	m, rec := newTestManager(fixture)
+
+	original := &ContainerConfig{
+		Name:         "api",
+		Image:        "app:1",
+		WorkingDir:   "/app",
+		Volumes:      []string{"/srv/data:/data"},
+		Networks:     []string{"app_default"},
+		PortBindings: []PortBinding{{HostPort: "8080", ContainerPort: "8080", Protocol: "tcp"}},
+		Labels:       map[string]string{"com.docker.compose.service": "api", "team": "core"},
+	}
+	dev, err := m.CreateDevContainer(context.Background(), original, "/tmp/dev/dlv")
+	if err != nil {
+		t.Fatalf("CreateDevContainer: %v", err)
+	}
+	if dev.Name != "api-dev" {
+		t.Errorf("dev container name = %q", dev.Name)
+	}
+
+	steps := rec.Steps()
+	if len(steps) != 2 {
+		t.Fatalf("expected entry script and docker create, got %+v", steps)
+	}
+
+	script := steps[0]
+	if script.Kind != executor.PlanStepScript || !strings.HasPrefix(script.Detail, "/tmp/dev/entry.sh\n") {
+		t.Errorf("first step should write the entry script, got %+v", script)
+	}
+	for _, want := range []string{
+		"STOP_SIGNAL=INT",
+		"link optional '/.dev-swap/service' '/app/api'",
+		"dlv exec '/.dev-swap/service' --headless --listen=:2345",
+		"if ! prepare; then",
+	} {
+		if !strings.Contains(script.Detail, want) {
+			t.Errorf("entry script missing %q", want)
+		}
+	}
+
+	create := steps[1]
+	if create.Kind != executor.PlanStepSession || !strings.HasPrefix(create.Detail, "docker create --name api-dev ") {
+		t.Fatalf("second step should create the dev container, got %+v", create)
+	}
+	for _, want := range []string{
+		"-v '/srv/data:/data'",
+		"-v '/tmp/dev:/.dev-swap:ro'",
+		"-v '/tmp/dev/entry.sh:/app/entry.sh'",
+		"-v '/tmp/dev/init.sh:/app/init.sh'",
+		"-v '/tmp/dev/dlv:/app/dlv'",
+		"-p 8080:8080",
+		"-p 2345:2345",
+		"--network app_default",
+		"-l 'dev-swap=true'",
+		"-l 'team=core'",
+		"app:1 'sh' '/app/init.sh'",
+	} {
+		if !strings.Contains(create.Detail, want) {
+			t.Errorf("docker create missing %q:\n%s", want, create.Detail)
+		}
+	}
+	if strings.Contains(create.Detail, "com.docker.compose") {
+		t.Errorf("compose labels must not be copied to the dev container:\n%s", create.Detail)
+	}
+}
+
+func TestCreateDevContainerStagesFileArtifacts(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "dev.yaml")
+	if err := os.WriteFile(file, []byte("debug: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fixture := executor.NewFixture()
+	fixture.Add("docker ps -q -a --filter name=^/api-dev$", "", nil)
+	m, rec := newTestManager(fixture)
+	m.config.Component.Artifacts = []config.Artifact{
+		{Name: "config", LocalPath: file, ContainerPath: "/app/config.yaml"},
+		{Name: "templates", LocalPath: dir, ContainerPath: "/app/templates"},
+	}
+
+	original := &ContainerConfig{Name: "api", Image: "app:1", WorkingDir: "/app"}
+	if _, err := m.CreateDevContainer(context.Background(), original, ""); err != nil {
+		t.Fatalf("CreateDevContainer: %v", err)
+	}
+	steps := rec.Steps()
+	if len(steps) != 2 {
+		t.Fatalf("expected entry script and docker create, got %+v", steps)
+	}
+
+	if want := "link required '/.dev-swap/artifacts/config/config.yaml' '/app/config.yaml'"; !strings.Contains(steps[0].Detail, want) {
+		t.Errorf("entry script missing %q:\n%s", want, steps[0].Detail)
+	}
+	create := steps[1].Detail
+	if !strings.Contains(create, "-v '/tmp/dev/artifacts/templates:/app/templates'") {
+		t.Errorf("directory artifact should be bind-mounted:\n%s", create)
+	}
+	if strings.Contains(create, ":/app/config.yaml") {
+		t.Errorf("file artifact must not be bind-mounted as a file:\n%s", create)
+	}
+}
+
+func TestCreateDevContainerRejectsLeftover(t *testing.T) {
+	fixture := executor.NewFixture()
+	fixture.Add("docker ps -q -a --filter name=^/api-dev$", "old1\n", nil)
+	fixture.Add("docker inspect --type container old1", `[{"Id": "old1", "Config": {"Labels": {"dev-swap": "true"}}}]`, nil)
+	m, rec := newTestManager(fixture)
+
+	_, err := m.CreateDevContainer(context.Background(), &ContainerConfig{Name: "api", Image: "app:1"}, "")
+	if err == nil || !strings.Contains(err.Error(), "發現殘留的開發容器") {
+		t.Fatalf("expected leftover container error, got %v", err)
+	}
+	if steps := rec.Steps(); len(steps) != 0 {
+		t.Errorf("nothing should be changed when a leftover exists, got %+v", steps)
+	}
+}
@@ -0,0 +1,96 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// 容器使用的 libc
+const (
+	LibcGlibc   = "glibc"
+	LibcMusl    = "musl"
+	LibcUnknown = "unknown"
+)
+
+// Platform 目標容器的執行平台
+type Platform struct {
+	OS      string // 例如 linux
+	Arch    string // 映像的架構，例如 amd64、arm64
+	Variant string // 例如 arm 的 v7
+	Libc    string // LibcGlibc / LibcMusl / LibcUnknown
+}
+
+func (p Platform) String() string {
+	s := p.OS + "/" + p.Arch
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s + " (" + p.Libc + ")"
+}
+
+// libcProbeScript 依動態連結器判斷容器使用的 libc
+const libcProbeScript = `if ls /lib/ld-musl-* >/dev/null 2>&1; then echo musl; ` +
+	`elif ls /lib64/ld-linux* /lib/ld-linux* /lib/*/ld-linux* >/dev/null 2>&1; then echo glibc; ` +
+	`else echo unknown; fi`
+
+// InspectPlatform 讀取原始容器映像的架構，並在原始容器運行中時探測其 libc
+// 原始容器未運行或沒有 shell（例如 distroless）時 libc 為 LibcUnknown
+func (m *Manager) InspectPlatform(ctx context.Context, original *ContainerConfig) (*Platform, error) {
+	cmd := m.cmdBuilder.Docker("image", "inspect", "--format", "'{{.Os}} {{.Architecture}} {{.Variant}}'", original.Image)
+	output, err := m.executor.Execute(ctx, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("讀取映像 %s 的平台失敗: %w", original.Image, err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("無法解析映像平台: %q", strings.TrimSpace(output))
+	}
+
+	platform := &Platform{OS: fields[0], Arch: fields[1], Libc: LibcUnknown}
+	if len(fields) > 2 {
+		platform.Variant = fields[2]
+	}
+
+	if original.Running && original.ContainerID != "" {
+		probe := m.cmdBuilder.Docker("exec", original.ContainerID, "sh", "-c", "'"+libcProbeScript+"'")
+		if output, err := m.executor.Execute(executor.WithReadOnly(ctx), probe); err == nil {
+			switch strings.TrimSpace(output) {
+			case LibcMusl:
+				platform.Libc = LibcMusl
+			case LibcGlibc:
+				platform.Libc = LibcGlibc
+			}
+		}
+	}
+	return platform, nil
+}
+
+// libSearchDirs 容器內搜尋動態庫的目錄
+const libSearchDirs = "/lib /lib64 /usr/lib /usr/lib64 /usr/local/lib /lib/*-linux-gnu /usr/lib/*-linux-gnu"
+
+// MissingLibraries 返回容器內找不到的動態連結器與函式庫
+// interpreter 為絕對路徑；libs 為函式庫名稱（DT_NEEDED），在常見的函式庫目錄中搜尋
+// 容器沒有 shell（例如 distroless）時返回錯誤
+func (m *Manager) MissingLibraries(ctx context.Context, container, interpreter string, libs []string) ([]string, error) {
+	var script strings.Builder
+	if interpreter != "" {
+		fmt.Fprintf(&script, "[ -e %s ] || echo %s; ", interpreter, interpreter)
+	}
+	if len(libs) > 0 {
+		fmt.Fprintf(&script, "for l in %s; do f=; for d in %s; do [ -e \"$d/$l\" ] && f=1 && break; done; [ -n \"$f\" ] || echo \"$l\"; done",
+			strings.Join(libs, " "), libSearchDirs)
+	}
+	if script.Len() == 0 {
+		return nil, nil
+	}
+
+	cmd := m.cmdBuilder.Docker("exec", container, "sh", "-c", shellQuote(script.String()))
+	output, err := m.executor.Execute(executor.WithReadOnly(ctx), cmd)
+	if err != nil {
+		return nil, fmt.Errorf("檢查容器 %s 的動態庫失敗: %w", container, err)
+	}
+	return strings.Fields(output), nil
+}
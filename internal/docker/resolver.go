@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// resolverTTL 服務名稱快取的有效時間
+	resolverTTL = 10 * time.Second
+	// resolverMissRefresh 查無名稱時重新整理的最短間隔，避免對不存在的名稱反覆查詢
+	resolverMissRefresh = 2 * time.Second
+)
+
+// ServiceResolver 將 compose 服務名稱、網路別名與容器名稱解析為容器在網路上的 IP
+// 只解析與指定容器（開發容器）位於相同網路上的容器
+type ServiceResolver struct {
+	manager       *Manager
+	containerName string
+
+	mu        sync.Mutex
+	hosts     map[string]string
+	refreshed time.Time
+}
+
+// NewServiceResolver 建立以 containerName 所在網路為範圍的解析器
+func (m *Manager) NewServiceResolver(containerName string) *ServiceResolver {
+	return &ServiceResolver{
+		manager:       m,
+		containerName: containerName,
+	}
+}
+
+// Resolve 返回名稱對應的 IP，名稱不是網路上的容器時 ok 為 false
+func (r *ServiceResolver) Resolve(ctx context.Context, name string) (ip string, ok bool, err error) {
+	name = strings.ToLower(name)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	age := time.Since(r.refreshed)
+	if r.hosts != nil && age < resolverTTL {
+		if ip, ok := r.hosts[name]; ok {
+			return ip, true, nil
+		}
+		if age < resolverMissRefresh {
+			return "", false, nil
+		}
+	}
+
+	if err := r.refresh(ctx); err != nil {
+		return "", false, err
+	}
+	ip, ok = r.hosts[name]
+	return ip, ok, nil
+}
+
+// refresh 重新讀取開發容器所在網路上的所有容器
+func (r *ServiceResolver) refresh(ctx context.Context) error {
+	backend := r.manager.backend
+	self, err := backend.InspectContainer(ctx, r.containerName)
+	if err != nil {
+		return fmt.Errorf("讀取容器 %s 的網路失敗: %w", r.containerName, err)
+	}
+
+	hosts := make(map[string]string)
+	for network := range self.NetworkSettings.Networks {
+		ids, err := backend.ListContainers(ctx, ListFilter{Network: network})
+		if err != nil {
+			return fmt.Errorf("列出網路 %s 上的容器失敗: %w", network, err)
+		}
+		for _, id := range ids {
+			info, err := backend.InspectContainer(ctx, id)
+			if err != nil {
+				continue
+			}
+			endpoint, ok := info.NetworkSettings.Networks[network]
+			if !ok || endpoint.IPAddress == "" {
+				continue
+			}
+
+			names := append([]string{strings.TrimPrefix(info.Name, "/")}, endpoint.Aliases...)
+			if service := info.Config.Labels["com.docker.compose.service"]; service != "" {
+				names = append(names, service)
+			}
+			for _, name := range names {
+				name = strings.ToLower(name)
+				if _, exists := hosts[name]; !exists && name != "" {
+					hosts[name] = endpoint.IPAddress
+				}
+			}
+		}
+	}
+
+	r.hosts = hosts
+	r.refreshed = time.Now()
+	return nil
+}
+
+// DialNetwork 以開發容器的網路視角連接 addr（host:port）
+// host 為同網路上的服務名稱時連接其容器 IP，否則從執行主機的角度連接
+// 本地模式（容器 IP 可能無法路由）透過開發容器內的 socat / nc 橋接
+func (m *Manager) DialNetwork(ctx context.Context, resolver *ServiceResolver, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("無效的端口: %s", portStr)
+	}
+
+	if net.ParseIP(host) == nil {
+		ip, ok, err := resolver.Resolve(ctx, host)
+		if err != nil {
+			log.Printf("解析服務名稱 %s 失敗: %v", host, err)
+		} else if ok {
+			host = ip
+		}
+	}
+
+	if !m.executor.IsRemote() {
+		return m.dialContainerExec(ctx, resolver.containerName, host, port)
+	}
+	return m.executor.Dial(ctx, "tcp", net.JoinHostPort(host, portStr))
+}
@@ -0,0 +1,190 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+)
+
+const (
+	// stageDir 遠端工作目錄在開發容器內的掛載位置（唯讀）
+	// 以目錄掛載，上傳後換成新檔案的執行檔在容器內可立即看到，不需要重啟容器
+	stageDir = config.ContainerStageDir
+
+	// entryScriptPath 開發容器內的 supervisor 腳本
+	entryScriptPath = "/app/entry.sh"
+
+	// supervisorStopTimeout 重新啟動或停止時等待程式結束的秒數，逾時後強制終止
+	supervisorStopTimeout = 10
+)
+
+// supervisorOptions 產生 supervisor 腳本的參數
+type supervisorOptions struct {
+	Command        string   // 程式的啟動命令（shell 語法），需以 exec 取代 shell，信號才能直接送達程式
+	Prepare        []string // 啟動 supervisor 時依序執行一次的命令，例如建立連結；任一失敗時 supervisor 以非零狀態結束
+	StopSignal     string   // 重新啟動或停止時送給程式的信號
+	RelaunchOnExit bool     // 程式自行結束時重新啟動（dlv dap 在編輯器中斷連線後即結束），否則 supervisor 隨之結束
+}
+
+// supervisorBody supervisor 的主體（POSIX sh，相容 busybox ash）
+//
+// 以 `sh /app/entry.sh restart`（docker exec）要求重新啟動程式：建立旗標檔後向 supervisor 送出 HUP，
+// 等到新程式啟動後輸出其啟動時間。沒有旗標檔的 HUP 以及 USR1 / USR2 / QUIT / WINCH 會轉送給程式，
+// 因此 docker kill --signal 仍能通知程式重新載入；TERM / INT 會停止程式並結束 supervisor。
+const supervisorBody = `SUPERVISOR_PID=/tmp/.dev-swap-supervisor.pid
+RESTART_FLAG=/tmp/.dev-swap-restart
+LAUNCHED_AT=/tmp/.dev-swap-launched
+
+if [ "$1" = "restart" ]; then
+	pid=$(cat "$SUPERVISOR_PID" 2>/dev/null)
+	if [ -z "$pid" ] || ! kill -0 "$pid" 2>/dev/null; then
+		echo "supervisor 未執行" >&2
+		exit 1
+	fi
+	touch "$RESTART_FLAG"
+	kill -HUP "$pid"
+	i=0
+	while [ -e "$RESTART_FLAG" ]; do
+		i=$((i + 1))
+		if [ "$i" -gt $(( (STOP_TIMEOUT + 5) * 10 )) ]; then
+			echo "supervisor 未在時限內重新啟動程式" >&2
+			exit 1
+		fi
+		sleep 0.1
+	done
+	cat "$LAUNCHED_AT"
+	exit 0
+fi
+
+# link [required|optional] <目標> <連結路徑>：建立符號連結
+# 容器以非 root 使用者執行或根檔案系統唯讀時無法建立，required 時讓 prepare 失敗，optional 只輸出警告
+link() {
+	if mkdir -p "$(dirname "$3")" 2>/dev/null && ln -sfn "$2" "$3" 2>/dev/null; then
+		return 0
+	fi
+	if [ "$1" = required ]; then
+		echo "[dev-swap] 無法建立連結 $3 → $2（容器以非 root 使用者執行或根檔案系統唯讀）" >&2
+		return 1
+	fi
+	echo "[dev-swap] 警告: 無法建立連結 $3 → $2（容器以非 root 使用者執行或根檔案系統唯讀），容器內的 $3 仍是映像原本的檔案" >&2
+	return 0
+}
+
+echo $$ > "$SUPERVISOR_PID"
+if ! prepare; then
+	echo "[dev-swap] 初始化失敗，supervisor 結束" >&2
+	exit 1
+fi
+
+child=
+killer=
+restarting=0
+stopping=0
+
+forward() {
+	[ -n "$child" ] && kill -"$1" "$child" 2>/dev/null
+}
+on_hup() {
+	if [ -e "$RESTART_FLAG" ]; then
+		restarting=1
+		forward "$STOP_SIGNAL"
+	else
+		forward HUP
+	fi
+}
+on_stop() {
+	stopping=1
+	forward "$STOP_SIGNAL"
+}
+trap on_hup HUP
+trap on_stop TERM INT
+for sig in USR1 USR2 QUIT WINCH; do
+	trap "forward $sig" "$sig"
+done
+
+while :; do
+	restarting=0
+	date +%s > "$LAUNCHED_AT"
+	run_app &
+	child=$!
+	rm -f "$RESTART_FLAG"
+
+	# wait 被信號中斷時立即返回，程式仍在執行就繼續等待；要求結束後逾時則強制終止
+	status=0
+	while kill -0 "$child" 2>/dev/null; do
+		wait "$child"
+		status=$?
+		if [ -z "$killer" ] && { [ "$restarting" = 1 ] || [ "$stopping" = 1 ]; }; then
+			(sleep "$STOP_TIMEOUT"; kill -KILL "$child" 2>/dev/null) &
+			killer=$!
+		fi
+	done
+	[ -n "$killer" ] && kill "$killer" 2>/dev/null
+	killer=
+	child=
+
+	if [ "$stopping" = 1 ]; then
+		exit "$status"
+	fi
+	if [ "$restarting" = 1 ]; then
+		echo "[dev-swap] 重新啟動程式"
+		continue
+	fi
+	if [ "$RELAUNCH_ON_EXIT" = 1 ]; then
+		sleep 1
+		continue
+	fi
+	exit "$status"
+done
+`
+
+// supervisorScript 產生開發容器的入口腳本
+func supervisorScript(opts supervisorOptions) string {
+	prepare := ":"
+	if len(opts.Prepare) > 0 {
+		prepare = strings.Join(opts.Prepare, " &&\n\t")
+	}
+	relaunch := 0
+	if opts.RelaunchOnExit {
+		relaunch = 1
+	}
+
+	var script strings.Builder
+	script.WriteString("#!/bin/sh\n")
+	script.WriteString("# docker-dev-swap supervisor：重新部署時只重新啟動程式，不重啟容器\n")
+	fmt.Fprintf(&script, "STOP_SIGNAL=%s\n", opts.StopSignal)
+	fmt.Fprintf(&script, "STOP_TIMEOUT=%d\n", supervisorStopTimeout)
+	fmt.Fprintf(&script, "RELAUNCH_ON_EXIT=%d\n\n", relaunch)
+	fmt.Fprintf(&script, "prepare() {\n\t%s\n}\n\n", prepare)
+	fmt.Fprintf(&script, "run_app() {\n\t%s\n}\n\n", opts.Command)
+	script.WriteString(supervisorBody)
+	return script.String()
+}
+
+// linkCommand 產生 prepare 中建立符號連結的命令，required 為 false 時失敗只輸出警告
+func linkCommand(required bool, target, linkPath string) string {
+	kind := "optional"
+	if required {
+		kind = "required"
+	}
+	return fmt.Sprintf("link %s %s %s", kind, shellQuote(target), shellQuote(linkPath))
+}
+
+// ReloadDevContainer 要求開發容器內的 supervisor 重新啟動程式，不重啟容器
+// 返回新程式的啟動時間（主機時鐘），供就緒檢查略過先前的日誌；容器未運行時返回錯誤
+func (m *Manager) ReloadDevContainer(ctx context.Context, name string) (time.Time, error) {
+	cmd := m.cmdBuilder.Docker("exec", name, "sh", entryScriptPath, "restart")
+	output, err := m.executor.Execute(ctx, cmd)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s", err, strings.TrimSpace(output))
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("無法解析程式的啟動時間: %q", strings.TrimSpace(output))
+	}
+	return time.Unix(sec, 0), nil
+}
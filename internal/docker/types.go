@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ContainerInspect docker inspect / Engine API 返回的容器資訊（僅包含本工具使用的欄位）
+type ContainerInspect struct {
+	ID    string `json:"Id"`
+	Name  string `json:"Name"`
+	State struct {
+		Status    string `json:"Status"`
+		Running   bool   `json:"Running"`
+		ExitCode  int    `json:"ExitCode"`
+		OOMKilled bool   `json:"OOMKilled"`
+		Error     string `json:"Error"`
+		StartedAt string `json:"StartedAt"` // RFC 3339，主機時鐘
+	} `json:"State"`
+	Config struct {
+		Image      string            `json:"Image"`
+		Env        []string          `json:"Env"`
+		Cmd        []string          `json:"Cmd"`
+		WorkingDir string            `json:"WorkingDir"`
+		Labels     map[string]string `json:"Labels"`
+		Tty        bool              `json:"Tty"`
+	} `json:"Config"`
+	HostConfig struct {
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Type        string `json:"Type"`
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+	NetworkSettings struct {
+		Networks map[string]struct {
+			NetworkID string   `json:"NetworkID"`
+			IPAddress string   `json:"IPAddress"`
+			Aliases   []string `json:"Aliases"`
+		} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// ContainerSpec 建立容器所需的配置，由各 Backend 轉換為 CLI 參數或 API 請求
+type ContainerSpec struct {
+	Name         string
+	Image        string
+	Platform     string // 例如 linux/arm64，空字串表示使用主機預設平台
+	Env          []string
+	Binds        []string // source:destination[:options]
+	PortBindings []PortBinding
+	Networks     []string
+	ExtraHosts   []string // host:ip，ip 可為 host-gateway
+	WorkingDir   string
+	Labels       map[string]string
+	Cmd          []string
+}
+
+// ListFilter 列出容器的過濾條件
+type ListFilter struct {
+	All     bool   // 是否包含已停止的容器
+	Name    string // 容器名稱（精確比對）
+	Network string // 只列出連接到此網路的容器
+}
+
+// LogsOptions 讀取容器日誌的選項
+type LogsOptions struct {
+	Follow bool
+	Tail   int       // 只讀取最後 N 行，<= 0 表示全部
+	Since  time.Time // 只讀取此時間之後的日誌，零值表示不限制
+}
+
+// LogStream 容器日誌流，stdout 與 stderr 分開提供，兩者都需持續讀取
+type LogStream struct {
+	Stdout io.Reader
+	Stderr io.Reader
+
+	wait  func() error
+	close func() error
+}
+
+// Wait 等待日誌流結束
+func (s *LogStream) Wait() error {
+	if s.wait == nil {
+		return nil
+	}
+	return s.wait()
+}
+
+// Close 關閉日誌流
+func (s *LogStream) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+// Event 容器事件（docker events / Engine API /events）
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time int64 `json:"time"`
+}
+
+// Backend 容器操作後端
+// 目前提供兩種實作：透過 docker CLI（cli）以及直接呼叫 Docker Engine API（api）
+type Backend interface {
+	// ListContainers 返回符合條件的容器 ID
+	ListContainers(ctx context.Context, filter ListFilter) ([]string, error)
+
+	// InspectContainer 返回容器詳細資訊
+	InspectContainer(ctx context.Context, nameOrID string) (*ContainerInspect, error)
+
+	// PullImage 拉取映像，platform 為空字串時使用主機預設平台
+	PullImage(ctx context.Context, image, platform string) error
+
+	// CreateContainer 建立容器（不啟動），返回容器 ID
+	CreateContainer(ctx context.Context, spec *ContainerSpec) (string, error)
+
+	// StartContainer 啟動容器
+	StartContainer(ctx context.Context, nameOrID string) error
+
+	// StopContainer 停止容器
+	StopContainer(ctx context.Context, nameOrID string) error
+
+	// RestartContainer 重啟容器
+	RestartContainer(ctx context.Context, nameOrID string) error
+
+	// SignalContainer 向容器的主程序送出信號（名稱不含 SIG 前綴，例如 HUP）
+	SignalContainer(ctx context.Context, nameOrID, signal string) error
+
+	// RemoveContainer 強制移除容器
+	RemoveContainer(ctx context.Context, nameOrID string) error
+
+	// Logs 讀取容器日誌
+	Logs(ctx context.Context, nameOrID string, opts LogsOptions) (*LogStream, error)
+
+	// Events 訂閱指定容器的事件，ctx 結束時關閉 channel
+	Events(ctx context.Context, containerName string) (<-chan Event, <-chan error)
+}
@@ -1,49 +1,132 @@
 package executor
 
 import (
+	"context"
+	"fmt"
 	"io"
+	"net"
+	"os"
 )
 
 // Session 定義了流式命令執行的統一接口
+//
+// 調用順序：RequestPTY（可選）→ Start → StdoutPipe / StderrPipe / StdinPipe → Wait / Close。
+// 非 PTY 模式下 stdout 與 stderr 為獨立管道，兩者都必須持續讀取，否則命令可能因緩衝區寫滿而阻塞。
 type Session interface {
+	// RequestPTY 請求分配偽終端，必須在 Start 之前調用
+	// PTY 模式下 stderr 會合併到 stdout
+	RequestPTY(term string, rows, cols int) error
+
 	// StdoutPipe 返回標準輸出管道
 	StdoutPipe() (io.Reader, error)
-	
-	// Start 啟動命令
-	Start(command string) error
-	
-	// Wait 等待命令完成
+
+	// StderrPipe 返回標準錯誤管道（PTY 模式下返回空 reader）
+	StderrPipe() (io.Reader, error)
+
+	// StdinPipe 返回標準輸入管道，關閉後命令會讀到 EOF
+	StdinPipe() (io.WriteCloser, error)
+
+	// Start 啟動命令，ctx 取消時會終止命令
+	Start(ctx context.Context, command string) error
+
+	// WindowChange 通知終端尺寸變更（僅 PTY 模式）
+	WindowChange(rows, cols int) error
+
+	// Signal 向命令發送信號
+	Signal(sig Signal) error
+
+	// Wait 等待命令完成，非正常退出時返回 *ExitError
 	Wait() error
-	
+
 	// Close 關閉 session
 	Close() error
 }
 
 // Executor 定義了執行操作的抽象接口
+// 所有涉及 I/O 的方法都接受 context，取消時會中斷正在進行的操作
 type Executor interface {
 	// Execute 執行 shell 指令
-	Execute(command string) (string, error)
-	
+	Execute(ctx context.Context, command string) (string, error)
+
 	// CreateSession 建立一個流式執行 session
-	CreateSession() (Session, error)
-	
-	// UploadFile 上傳/複製檔案
-	UploadFile(localPath, remotePath string) error
-	
-	// CreateScript 建立腳本檔案
-	CreateScript(script, path string) error
-	
-	// CreateTunnel 建立 SSH tunnel (僅遠端模式)
-	CreateTunnel(localPort, remotePort int) (TunnelCloser, error)
-	
+	CreateSession(ctx context.Context) (Session, error)
+
+	// UploadFile 上傳/複製檔案，權限設為可執行（0755）
+	UploadFile(ctx context.Context, localPath, remotePath string) error
+
+	// UploadFileMode 上傳/複製檔案並設定權限，用於設定檔等非執行檔
+	UploadFileMode(ctx context.Context, localPath, remotePath string, mode os.FileMode) error
+
+	// CreateScript 建立腳本檔案，內容原樣寫入
+	CreateScript(ctx context.Context, script, path string) error
+
+	// ExpandScript 以登入使用者（不經 sudo）在執行主機上用 echo -e 展開腳本中的 $ 變數、命令替換與跳脫字元
+	// 用於 initial_scripts 的既有行為；本地模式原樣返回
+	ExpandScript(ctx context.Context, script string) (string, error)
+
+	// Dial 從執行主機的角度建立連線（本地直接連線，遠端經由 SSH 轉發）
+	// network 支援 "tcp" 與 "unix"，例如連線 Docker Engine 的 unix socket
+	Dial(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// CreateTunnel 依 local 的端口分配策略在本地監聽並轉發到 target，ctx 結束時自動關閉
+	// 遠端模式經由 SSH；本地模式目標即為本機同一端口時不需要轉發，返回空的 closer
+	CreateTunnel(ctx context.Context, local LocalPort, target TunnelTarget) (TunnelCloser, error)
+
+	// CreateReverseTunnel 在執行主機的 remoteAddr 監聽，並將連線轉發到本地 localAddr，ctx 結束時自動關閉
+	// 遠端模式使用 SSH 遠端端口轉發；本地模式服務本來就在本機，返回空的 closer
+	CreateReverseTunnel(ctx context.Context, remoteAddr, localAddr string) (TunnelCloser, error)
+
 	// Close 關閉連接
 	Close() error
-	
+
 	// IsRemote 判斷是否為遠端模式
 	IsRemote() bool
 }
 
+// TunnelTarget tunnel 的轉發目標
+type TunnelTarget struct {
+	Addr string   // 執行主機上的目標地址（host:port），Dial 為 nil 時直接連線此地址，否則僅供顯示
+	Dial DialFunc // 自訂連線方式，例如每次連線時重新解析容器 IP
+}
+
 // TunnelCloser 定義了 tunnel 的關閉接口
 type TunnelCloser interface {
 	Close() error
+
+	// ListenPort 返回實際監聽的端口（反向轉發為遠端端口，未監聽時為請求的端口）
+	ListenPort() int
+}
+
+// Signal 命令信號名稱（與 SSH 協議的信號名稱一致，不含 SIG 前綴）
+type Signal string
+
+const (
+	SignalHUP  Signal = "HUP"
+	SignalINT  Signal = "INT"
+	SignalQUIT Signal = "QUIT"
+	SignalKILL Signal = "KILL"
+	SignalTERM Signal = "TERM"
+	SignalUSR1 Signal = "USR1"
+	SignalUSR2 Signal = "USR2"
+)
+
+// ExitError 描述命令非正常結束時的退出狀態
+type ExitError struct {
+	Code   int    // 退出碼，被信號終止或狀態未知時為 -1
+	Signal Signal // 終止命令的信號，正常退出時為空
+	Err    error  // 底層錯誤
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("命令被信號 %s 終止", e.Signal)
+	}
+	if e.Code < 0 {
+		return fmt.Sprintf("命令結束但未返回退出狀態: %v", e.Err)
+	}
+	return fmt.Sprintf("命令退出碼 %d", e.Code)
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
 }
@@ -1,11 +1,14 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 
 	"github.com/laysdragon/go-docker-dev-swap/internal/config"
 )
@@ -24,25 +27,42 @@ func NewLocalExecutor(rc *config.RuntimeConfig) (*LocalExecutor, error) {
 	}, nil
 }
 
-func (e *LocalExecutor) Execute(command string) (string, error) {
+func (e *LocalExecutor) Execute(ctx context.Context, command string) (string, error) {
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Command)
+	defer cancel()
+
 	// 使用 sudo wrapper 包装命令
 	wrappedCmd := e.sudoWrapper.Wrap(command)
 	
-	cmd := exec.Command("bash", "-c", wrappedCmd)
+	cmd := exec.CommandContext(ctx, "bash", "-c", wrappedCmd)
+	setupProcessGroup(cmd)
 	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return string(output), fmt.Errorf("執行命令中斷: %w (%s)", ctxErr, wrappedCmd)
+	}
 	if err != nil {
 		return string(output), fmt.Errorf("執行命令失敗: %w (%s)=>(%s)", err, wrappedCmd, output)
 	}
 	return string(output), nil
 }
 
-func (e *LocalExecutor) CreateSession() (Session, error) {
+func (e *LocalExecutor) CreateSession(ctx context.Context) (Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return &LocalSession{
 		sudoWrapper: e.sudoWrapper,
 	}, nil
 }
 
-func (e *LocalExecutor) UploadFile(localPath, destPath string) error {
+func (e *LocalExecutor) UploadFile(ctx context.Context, localPath, destPath string) error {
+	return e.UploadFileMode(ctx, localPath, destPath, 0755)
+}
+
+func (e *LocalExecutor) UploadFileMode(ctx context.Context, localPath, destPath string, mode os.FileMode) error {
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Upload)
+	defer cancel()
+
 	// 本地模式直接複製檔案
 	sourceFile, err := os.Open(localPath)
 	if err != nil {
@@ -71,36 +91,93 @@ func (e *LocalExecutor) UploadFile(localPath, destPath string) error {
 	defer destFile.Close()
 
 	// 複製檔案內容
-	if _, err := io.Copy(destFile, sourceFile); err != nil {
+	if _, err := io.Copy(destFile, &contextReader{ctx: ctx, reader: sourceFile}); err != nil {
 		return fmt.Errorf("複製檔案失敗: %w", err)
 	}
 
-	// 設定執行權限
-	if err := os.Chmod(destPath, 0755); err != nil {
+	// 設定檔案權限
+	if err := os.Chmod(destPath, mode); err != nil {
 		return fmt.Errorf("設定檔案權限失敗: %w", err)
 	}
 
 	return nil
 }
 
-func (e *LocalExecutor) CreateScript(script, path string) error {
+func (e *LocalExecutor) CreateScript(ctx context.Context, script, path string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("建立腳本中斷: %w", err)
+	}
+
 	// 建立目錄
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("建立目錄失敗: %w", err)
 	}
 
-	// 寫入腳本
-	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+	// 寫入暫存檔後 rename 取代，正在執行舊腳本的 shell 不會讀到寫到一半的內容
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(script), 0755); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("寫入腳本失敗: %w", err)
+	}
+	if err := os.Chmod(tmp, 0755); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("賦予腳本執行權限失敗: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
 		return fmt.Errorf("寫入腳本失敗: %w", err)
 	}
 
 	return nil
 }
 
-func (e *LocalExecutor) CreateTunnel(localPort, remotePort int) (TunnelCloser, error) {
-	// 本地模式不需要 tunnel
-	return &noopCloser{}, nil
+// ExpandScript 本地模式的腳本一向原樣寫入，不做展開
+func (e *LocalExecutor) ExpandScript(ctx context.Context, script string) (string, error) {
+	return script, nil
+}
+
+func (e *LocalExecutor) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, addr)
+}
+
+func (e *LocalExecutor) CreateTunnel(ctx context.Context, local LocalPort, target TunnelTarget) (TunnelCloser, error) {
+	dial := target.Dial
+	if dial == nil {
+		// 本地模式目標就是本機同一端口時不需要 tunnel
+		if host, port, err := net.SplitHostPort(target.Addr); err == nil && port == strconv.Itoa(local.Port) && isLoopback(host) {
+			return &noopCloser{port: local.Port}, nil
+		}
+		dial = func(ctx context.Context) (net.Conn, error) {
+			return e.Dial(ctx, "tcp", target.Addr)
+		}
+	}
+	tunnel, err := NewTunnel(ctx, local, dial)
+	if err != nil {
+		return nil, err
+	}
+	tunnel.SetLogConnections(e.config.Host.LogTunnelConnections)
+	return tunnel, nil
+}
+
+// CreateReverseTunnel 本地模式不建立任何監聽，返回空的 closer
+// 容器經由 host gateway（bridge 網路的 gateway 地址）直接連接本機服務，只監聽 127.0.0.1 的本地服務因此無法從容器連入
+func (e *LocalExecutor) CreateReverseTunnel(ctx context.Context, remoteAddr, localAddr string) (TunnelCloser, error) {
+	port := 0
+	if _, p, err := net.SplitHostPort(localAddr); err == nil {
+		port, _ = strconv.Atoi(p)
+	}
+	return &noopCloser{port: port}, nil
+}
+
+// isLoopback 判斷主機名稱是否指向本機
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
 func (e *LocalExecutor) Close() error {
@@ -1,16 +1,40 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"strings"
 )
 
 // LocalSession 本地命令執行 session
 type LocalSession struct {
 	cmd         *exec.Cmd
-	stdout      io.ReadCloser
+	stdout      io.Reader
+	stderr      io.Reader
+	stdin       io.WriteCloser
 	sudoWrapper *SudoWrapper
+
+	// PTY 模式
+	usePTY   bool
+	ptyRows  int
+	ptyCols  int
+	ptyTerm  string
+	ptyFile  *os.File
+	ptySlave *os.File
+}
+
+func (s *LocalSession) RequestPTY(term string, rows, cols int) error {
+	if s.cmd != nil {
+		return fmt.Errorf("必須在 Start 之前請求 PTY")
+	}
+	s.usePTY = true
+	s.ptyTerm = term
+	s.ptyRows = rows
+	s.ptyCols = cols
+	return nil
 }
 
 func (s *LocalSession) StdoutPipe() (io.Reader, error) {
@@ -20,38 +44,138 @@ func (s *LocalSession) StdoutPipe() (io.Reader, error) {
 	return nil, fmt.Errorf("必須先調用 Start")
 }
 
-func (s *LocalSession) Start(command string) error {
-	// 使用 sudo wrapper 包裝命令
+func (s *LocalSession) StderrPipe() (io.Reader, error) {
+	if s.stderr != nil {
+		return s.stderr, nil
+	}
+	return nil, fmt.Errorf("必須先調用 Start")
+}
+
+func (s *LocalSession) StdinPipe() (io.WriteCloser, error) {
+	if s.stdin != nil {
+		return s.stdin, nil
+	}
+	return nil, fmt.Errorf("必須先調用 Start")
+}
+
+func (s *LocalSession) Start(ctx context.Context, command string) error {
+	// 使用 sudo wrapper 包裝命令（保留標準輸入）
 	wrappedCmd := command
 	if s.sudoWrapper != nil {
-		wrappedCmd = s.sudoWrapper.Wrap(command)
+		wrappedCmd = s.sudoWrapper.WrapInteractive(command)
 	}
-	
-	s.cmd = exec.Command("bash", "-c", wrappedCmd)
-	
+
+	// ctx 取消時終止整個進程組
+	s.cmd = exec.CommandContext(ctx, "bash", "-c", wrappedCmd)
+	setupProcessGroup(s.cmd)
+
+	if s.usePTY {
+		if err := s.preparePTY(); err != nil {
+			return err
+		}
+	} else if err := s.preparePipes(); err != nil {
+		return err
+	}
+
+	if err := s.cmd.Start(); err != nil {
+		s.closePTY()
+		return fmt.Errorf("啟動命令失敗: %w", err)
+	}
+
+	// 子進程已繼承 slave，父進程不再需要
+	if s.ptySlave != nil {
+		s.ptySlave.Close()
+		s.ptySlave = nil
+	}
+	return nil
+}
+
+// preparePipes 建立獨立的 stdin / stdout / stderr 管道
+func (s *LocalSession) preparePipes() error {
 	stdout, err := s.cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("獲取標準輸出失敗: %w", err)
 	}
+	stderr, err := s.cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("獲取標準錯誤失敗: %w", err)
+	}
+	stdin, err := s.cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("獲取標準輸入失敗: %w", err)
+	}
 	s.stdout = stdout
-	
-	if err := s.cmd.Start(); err != nil {
-		return fmt.Errorf("啟動命令失敗: %w", err)
+	s.stderr = stderr
+	s.stdin = stdin
+	return nil
+}
+
+// preparePTY 分配 PTY 並將命令的標準輸入輸出接到 slave 端
+func (s *LocalSession) preparePTY() error {
+	master, slave, err := openPTY()
+	if err != nil {
+		return err
+	}
+	s.ptyFile = master
+	s.ptySlave = slave
+
+	if s.ptyRows > 0 && s.ptyCols > 0 {
+		if err := setPTYSize(master, s.ptyRows, s.ptyCols); err != nil {
+			s.closePTY()
+			return fmt.Errorf("設定終端尺寸失敗: %w", err)
+		}
 	}
-	
+
+	attachPTY(s.cmd, slave)
+	if s.ptyTerm != "" {
+		s.cmd.Env = append(os.Environ(), "TERM="+s.ptyTerm)
+	}
+
+	s.stdout = &ptyReader{file: master}
+	s.stderr = strings.NewReader("")
+	s.stdin = master
 	return nil
 }
 
+func (s *LocalSession) closePTY() {
+	if s.ptySlave != nil {
+		s.ptySlave.Close()
+		s.ptySlave = nil
+	}
+	if s.ptyFile != nil {
+		s.ptyFile.Close()
+		s.ptyFile = nil
+	}
+}
+
+func (s *LocalSession) WindowChange(rows, cols int) error {
+	if s.ptyFile == nil {
+		return fmt.Errorf("session 未使用 PTY")
+	}
+	return setPTYSize(s.ptyFile, rows, cols)
+}
+
+func (s *LocalSession) Signal(sig Signal) error {
+	if s.cmd == nil {
+		return fmt.Errorf("必須先調用 Start")
+	}
+	return signalProcessGroup(s.cmd, sig)
+}
+
 func (s *LocalSession) Wait() error {
 	if s.cmd == nil {
 		return nil
 	}
-	return s.cmd.Wait()
+	if err := s.cmd.Wait(); err != nil {
+		return exitErrorFromWait(err)
+	}
+	return nil
 }
 
 func (s *LocalSession) Close() error {
+	defer s.closePTY()
 	if s.cmd != nil && s.cmd.Process != nil {
-		return s.cmd.Process.Kill()
+		return killProcessGroup(s.cmd)
 	}
 	return nil
 }
@@ -0,0 +1,46 @@
+//go:build !unix
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// processWaitDelay ctx 取消後等待子進程輸出管道關閉的最長時間
+const processWaitDelay = 2 * time.Second
+
+// setupProcessGroup 非 unix 平台不支援進程組，僅終止主進程
+func setupProcessGroup(cmd *exec.Cmd) {
+	cmd.WaitDelay = processWaitDelay
+}
+
+// killProcessGroup 非 unix 平台僅終止主進程
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	return cmd.Process.Kill()
+}
+
+// signalProcessGroup 非 unix 平台僅支援終止主進程
+func signalProcessGroup(cmd *exec.Cmd, sig Signal) error {
+	if cmd.Process == nil {
+		return fmt.Errorf("命令尚未啟動")
+	}
+	if sig != SignalKILL {
+		return fmt.Errorf("此平台不支援信號: %s", sig)
+	}
+	return cmd.Process.Kill()
+}
+
+// exitErrorFromWait 將 exec.Cmd.Wait 的錯誤轉換為 *ExitError
+func exitErrorFromWait(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+	return &ExitError{Code: exitErr.ExitCode(), Err: err}
+}
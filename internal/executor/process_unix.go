@@ -0,0 +1,79 @@
+//go:build unix
+
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// processWaitDelay ctx 取消後等待子進程輸出管道關閉的最長時間
+const processWaitDelay = 2 * time.Second
+
+// setupProcessGroup 讓命令在獨立的進程組中執行，取消時終止整個進程組
+// 避免 bash -c 產生的子進程（例如 docker compose）在取消後殘留
+func setupProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+	cmd.WaitDelay = processWaitDelay
+}
+
+// killProcessGroup 終止命令所在的進程組，失敗時退回只終止主進程
+func killProcessGroup(cmd *exec.Cmd) error {
+	if cmd.Process == nil {
+		return nil
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
+
+// signalToSyscall 將 Signal 轉換為系統信號
+var signalToSyscall = map[Signal]syscall.Signal{
+	SignalHUP:  syscall.SIGHUP,
+	SignalINT:  syscall.SIGINT,
+	SignalQUIT: syscall.SIGQUIT,
+	SignalKILL: syscall.SIGKILL,
+	SignalTERM: syscall.SIGTERM,
+	SignalUSR1: syscall.SIGUSR1,
+	SignalUSR2: syscall.SIGUSR2,
+}
+
+// signalProcessGroup 向命令所在的進程組發送信號
+func signalProcessGroup(cmd *exec.Cmd, sig Signal) error {
+	if cmd.Process == nil {
+		return fmt.Errorf("命令尚未啟動")
+	}
+	sysSig, ok := signalToSyscall[sig]
+	if !ok {
+		return fmt.Errorf("不支援的信號: %s", sig)
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, sysSig); err != nil {
+		return cmd.Process.Signal(sysSig)
+	}
+	return nil
+}
+
+// exitErrorFromWait 將 exec.Cmd.Wait 的錯誤轉換為 *ExitError
+func exitErrorFromWait(err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	result := &ExitError{Code: exitErr.ExitCode(), Err: err}
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Code = -1
+		result.Signal = Signal(strings.TrimPrefix(unix.SignalName(status.Signal()), "SIG"))
+	}
+	return result
+}
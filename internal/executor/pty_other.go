@@ -0,0 +1,29 @@
+//go:build !linux && !darwin
+
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// openPTY 此平台（Windows 等）不支援本地 PTY，本地模式的互動式 shell 無法使用；遠端模式經由 SSH 分配 PTY 不受影響
+func openPTY() (master, slave *os.File, err error) {
+	return nil, nil, fmt.Errorf("此平台不支援本地 PTY")
+}
+
+func setPTYSize(f *os.File, rows, cols int) error {
+	return fmt.Errorf("此平台不支援本地 PTY")
+}
+
+func attachPTY(cmd *exec.Cmd, slave *os.File) {}
+
+type ptyReader struct {
+	file *os.File
+}
+
+func (r *ptyReader) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
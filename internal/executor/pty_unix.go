@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+package executor
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setPTYSize 設定終端尺寸，核心會自動向前景進程組發送 SIGWINCH
+func setPTYSize(f *os.File, rows, cols int) error {
+	return unix.IoctlSetWinsize(int(f.Fd()), unix.TIOCSWINSZ, &unix.Winsize{
+		Row: uint16(rows),
+		Col: uint16(cols),
+	})
+}
+
+// attachPTY 讓命令以 slave 作為控制終端，並在新的 session 中執行
+func attachPTY(cmd *exec.Cmd, slave *os.File) {
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	// 新 session 的 leader 同時是進程組 leader，取消時仍可終止整個進程組
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
+
+// ptyReader 將子進程結束後 master 端返回的 EIO 轉換為 EOF
+type ptyReader struct {
+	file *os.File
+}
+
+func (r *ptyReader) Read(p []byte) (int, error) {
+	n, err := r.file.Read(p)
+	if err != nil && errors.Is(err, syscall.EIO) {
+		return n, io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,461 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+)
+
+// PlanStepKind 乾跑計畫中的操作類型
+type PlanStepKind string
+
+const (
+	PlanStepCommand PlanStepKind = "指令"
+	PlanStepSession PlanStepKind = "流式指令"
+	PlanStepUpload  PlanStepKind = "上傳"
+	PlanStepScript  PlanStepKind = "腳本"
+	PlanStepTunnel  PlanStepKind = "Tunnel"
+	PlanStepDial    PlanStepKind = "連線"
+)
+
+// PlanStep 乾跑模式下記錄的單一變更操作
+type PlanStep struct {
+	Kind   PlanStepKind
+	Detail string
+}
+
+// readOnlyDockerVerbs 不會改變主機狀態的 docker 子命令
+var readOnlyDockerVerbs = map[string]bool{
+	"ps":                true,
+	"inspect":           true,
+	"logs":              true,
+	"events":            true,
+	"version":           true,
+	"info":              true,
+	"images":            true,
+	"port":              true,
+	"top":               true,
+	"container inspect": true,
+	"container ls":      true,
+	"image inspect":     true,
+	"image ls":          true,
+	"network inspect":   true,
+	"network ls":        true,
+	"volume inspect":    true,
+	"volume ls":         true,
+}
+
+// readOnlyComposeVerbs 不會改變主機狀態的 docker compose 子命令
+var readOnlyComposeVerbs = map[string]bool{
+	"ps":     true,
+	"config": true,
+	"ls":     true,
+	"images": true,
+}
+
+type readOnlyKey struct{}
+
+// WithReadOnly 標記 ctx 下執行的命令為唯讀查詢
+// 用於無法從命令內容判斷的探測（例如透過 docker exec 讀取容器資訊），乾跑時會交給真實主機回答
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// IsReadOnly 判斷 ctx 是否標記為唯讀查詢
+func IsReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// RecordingExecutor 乾跑用的 Executor
+// 唯讀查詢交給真實主機（inner）或 fixture 回答，其餘變更操作只記錄、不執行
+type RecordingExecutor struct {
+	inner         Executor
+	fixture       *Fixture
+	dockerCommand string
+	composeCmd    string
+	remote        bool
+
+	mu       sync.Mutex
+	steps    []PlanStep
+	reads    int
+	captured *Fixture
+}
+
+// NewRecordingExecutor 建立乾跑用的 Executor
+// inner 為 nil 時唯讀查詢只從 fixture 回答；fixture 為 nil 時唯讀查詢全部交給 inner
+func NewRecordingExecutor(inner Executor, fixture *Fixture, rc *config.RuntimeConfig) *RecordingExecutor {
+	return &RecordingExecutor{
+		inner:         inner,
+		fixture:       fixture,
+		dockerCommand: rc.DockerCommand,
+		composeCmd:    rc.DockerComposeCommand,
+		remote:        rc.Mode == "remote",
+		captured:      NewFixture(),
+	}
+}
+
+// Steps 返回目前記錄的所有變更操作
+func (e *RecordingExecutor) Steps() []PlanStep {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]PlanStep(nil), e.steps...)
+}
+
+// CapturedFixture 返回從真實主機取得的唯讀查詢結果，可存檔供之後離線乾跑使用
+func (e *RecordingExecutor) CapturedFixture() *Fixture {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.captured
+}
+
+// WritePlan 以可讀格式輸出執行計畫
+func (e *RecordingExecutor) WritePlan(w io.Writer) {
+	steps := e.Steps()
+	e.mu.Lock()
+	reads := e.reads
+	e.mu.Unlock()
+
+	fmt.Fprintln(w, "========== 執行計畫（乾跑，未對主機做任何變更） ==========")
+	if len(steps) == 0 {
+		fmt.Fprintln(w, "（沒有任何變更操作）")
+	}
+	for i, step := range steps {
+		lines := strings.Split(strings.TrimRight(step.Detail, "\n"), "\n")
+		fmt.Fprintf(w, "%3d. [%s] %s\n", i+1, step.Kind, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "       | %s\n", line)
+		}
+	}
+	fmt.Fprintf(w, "另有 %d 次唯讀查詢（未列出）\n", reads)
+	fmt.Fprintln(w, "==========================================================")
+}
+
+func (e *RecordingExecutor) record(kind PlanStepKind, detail string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.steps = append(e.steps, PlanStep{Kind: kind, Detail: detail})
+}
+
+// shellMetaSequences 會讓命令執行 docker 查詢以外動作的 shell 語法
+var shellMetaSequences = []string{";", "|", "&", ">", "<", "`", "$(", "\n"}
+
+// hasShellMeta 判斷命令是否包含串接、管線、重導向或命令替換
+func hasShellMeta(command string) bool {
+	for _, seq := range shellMetaSequences {
+		if strings.Contains(command, seq) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnly 判斷命令是否為唯讀查詢
+func (e *RecordingExecutor) isReadOnly(ctx context.Context, command string) bool {
+	if IsReadOnly(ctx) {
+		return true
+	}
+
+	// 去掉 "cd <dir> &&" 前綴
+	cmd := strings.TrimSpace(command)
+	if strings.HasPrefix(cmd, "cd ") {
+		dir, rest, ok := strings.Cut(cmd, "&&")
+		if !ok || hasShellMeta(dir) {
+			return false
+		}
+		cmd = strings.TrimSpace(rest)
+	}
+	// 串接、管線、重導向或命令替換可能夾帶其他命令，一律視為變更操作
+	if hasShellMeta(cmd) {
+		return false
+	}
+
+	if e.composeCmd != "" && strings.HasPrefix(cmd, e.composeCmd+" ") {
+		fields := strings.Fields(strings.TrimPrefix(cmd, e.composeCmd))
+		return len(fields) > 0 && readOnlyComposeVerbs[fields[0]]
+	}
+	if e.dockerCommand != "" && strings.HasPrefix(cmd, e.dockerCommand+" ") {
+		fields := strings.Fields(strings.TrimPrefix(cmd, e.dockerCommand))
+		if len(fields) == 0 {
+			return false
+		}
+		verb := fields[0]
+		if len(fields) > 1 && (verb == "container" || verb == "image" || verb == "network" || verb == "volume") {
+			verb += " " + fields[1]
+		}
+		return readOnlyDockerVerbs[verb]
+	}
+	return false
+}
+
+func (e *RecordingExecutor) Execute(ctx context.Context, command string) (string, error) {
+	if !e.isReadOnly(ctx, command) {
+		e.record(PlanStepCommand, command)
+		return "", nil
+	}
+
+	e.mu.Lock()
+	e.reads++
+	e.mu.Unlock()
+
+	if e.fixture != nil {
+		if entry, ok := e.fixture.Lookup(command); ok {
+			return entry.result()
+		}
+		if e.inner == nil {
+			return "", fmt.Errorf("fixture 中找不到命令的輸出: %s", command)
+		}
+	}
+	if e.inner == nil {
+		return "", fmt.Errorf("乾跑模式未連接主機，無法執行查詢: %s", command)
+	}
+
+	output, err := e.inner.Execute(ctx, command)
+	e.mu.Lock()
+	e.captured.Add(command, output, err)
+	e.mu.Unlock()
+	return output, err
+}
+
+func (e *RecordingExecutor) CreateSession(ctx context.Context) (Session, error) {
+	return &recordingSession{executor: e, ctx: ctx}, nil
+}
+
+func (e *RecordingExecutor) UploadFile(ctx context.Context, localPath, remotePath string) error {
+	return e.UploadFileMode(ctx, localPath, remotePath, 0755)
+}
+
+func (e *RecordingExecutor) UploadFileMode(ctx context.Context, localPath, remotePath string, mode os.FileMode) error {
+	detail := fmt.Sprintf("%s → %s", localPath, remotePath)
+	if mode != 0755 {
+		detail += fmt.Sprintf(" [%04o]", mode)
+	}
+	if info, err := os.Stat(localPath); err != nil {
+		detail += "（本地檔案不存在）"
+	} else {
+		detail += fmt.Sprintf("（%d bytes）", info.Size())
+	}
+	e.record(PlanStepUpload, detail)
+	return nil
+}
+
+func (e *RecordingExecutor) CreateScript(ctx context.Context, script, path string) error {
+	e.record(PlanStepScript, fmt.Sprintf("%s\n%s", path, strings.TrimSpace(script)))
+	return nil
+}
+
+// ExpandScript 展開可能執行命令替換，乾跑時只記錄並原樣返回
+func (e *RecordingExecutor) ExpandScript(ctx context.Context, script string) (string, error) {
+	if e.remote && script != "" {
+		e.record(PlanStepCommand, fmt.Sprintf("echo -e \"%s\"（以登入使用者展開腳本）", script))
+	}
+	return script, nil
+}
+
+func (e *RecordingExecutor) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	e.record(PlanStepDial, fmt.Sprintf("%s %s", network, addr))
+	if e.inner == nil {
+		return nil, fmt.Errorf("乾跑模式未連接主機，無法建立連線: %s", addr)
+	}
+	return e.inner.Dial(ctx, network, addr)
+}
+
+func (e *RecordingExecutor) CreateTunnel(ctx context.Context, local LocalPort, target TunnelTarget) (TunnelCloser, error) {
+	e.record(PlanStepTunnel, fmt.Sprintf("localhost:%d（%s）→ %s", local.Port, local.Policy, target.Addr))
+	return &noopCloser{port: local.Port}, nil
+}
+
+func (e *RecordingExecutor) CreateReverseTunnel(ctx context.Context, remoteAddr, localAddr string) (TunnelCloser, error) {
+	if e.remote {
+		e.record(PlanStepTunnel, fmt.Sprintf("遠端 %s → 本地 %s", remoteAddr, localAddr))
+	}
+	return &noopCloser{}, nil
+}
+
+func (e *RecordingExecutor) Close() error {
+	if e.inner != nil {
+		return e.inner.Close()
+	}
+	return nil
+}
+
+func (e *RecordingExecutor) IsRemote() bool {
+	return e.remote
+}
+
+// recordingSession 乾跑用的 Session：唯讀命令交給真實主機，其餘只記錄並立即結束
+type recordingSession struct {
+	executor *RecordingExecutor
+	ctx      context.Context
+	inner    Session
+	ptyTerm  string
+	ptyRows  int
+	ptyCols  int
+	usePTY   bool
+}
+
+func (s *recordingSession) RequestPTY(term string, rows, cols int) error {
+	s.usePTY = true
+	s.ptyTerm, s.ptyRows, s.ptyCols = term, rows, cols
+	return nil
+}
+
+func (s *recordingSession) Start(ctx context.Context, command string) error {
+	if !s.executor.isReadOnly(s.ctx, command) || s.executor.inner == nil {
+		s.executor.record(PlanStepSession, command)
+		return nil
+	}
+
+	inner, err := s.executor.inner.CreateSession(ctx)
+	if err != nil {
+		return err
+	}
+	if s.usePTY {
+		if err := inner.RequestPTY(s.ptyTerm, s.ptyRows, s.ptyCols); err != nil {
+			inner.Close()
+			return err
+		}
+	}
+	if err := inner.Start(ctx, command); err != nil {
+		inner.Close()
+		return err
+	}
+	s.inner = inner
+	return nil
+}
+
+func (s *recordingSession) StdoutPipe() (io.Reader, error) {
+	if s.inner != nil {
+		return s.inner.StdoutPipe()
+	}
+	return strings.NewReader(""), nil
+}
+
+func (s *recordingSession) StderrPipe() (io.Reader, error) {
+	if s.inner != nil {
+		return s.inner.StderrPipe()
+	}
+	return strings.NewReader(""), nil
+}
+
+func (s *recordingSession) StdinPipe() (io.WriteCloser, error) {
+	if s.inner != nil {
+		return s.inner.StdinPipe()
+	}
+	return nopWriteCloser{io.Discard}, nil
+}
+
+func (s *recordingSession) WindowChange(rows, cols int) error {
+	if s.inner != nil {
+		return s.inner.WindowChange(rows, cols)
+	}
+	return nil
+}
+
+func (s *recordingSession) Signal(sig Signal) error {
+	if s.inner != nil {
+		return s.inner.Signal(sig)
+	}
+	return nil
+}
+
+func (s *recordingSession) Wait() error {
+	if s.inner != nil {
+		return s.inner.Wait()
+	}
+	return nil
+}
+
+func (s *recordingSession) Close() error {
+	if s.inner != nil {
+		return s.inner.Close()
+	}
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// Fixture 預先擷取的唯讀命令輸出，讓乾跑或測試不需要連接真實主機
+type Fixture struct {
+	Commands []FixtureEntry `json:"commands"`
+}
+
+// FixtureEntry 單一命令的輸出
+type FixtureEntry struct {
+	Command string `json:"command"`         // 完整命令（未經 sudo 包裝）
+	Output  string `json:"output"`          // 命令輸出
+	Error   string `json:"error,omitempty"` // 非空時表示命令失敗
+}
+
+func (e FixtureEntry) result() (string, error) {
+	if e.Error != "" {
+		return e.Output, fmt.Errorf("%s", e.Error)
+	}
+	return e.Output, nil
+}
+
+// NewFixture 建立空的 fixture
+func NewFixture() *Fixture {
+	return &Fixture{}
+}
+
+// LoadFixture 從 JSON 檔案載入 fixture
+func LoadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取 fixture 失敗: %w", err)
+	}
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("解析 fixture 失敗: %w", err)
+	}
+	return &fixture, nil
+}
+
+// Save 將 fixture 寫入 JSON 檔案
+func (f *Fixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("編碼 fixture 失敗: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("寫入 fixture 失敗: %w", err)
+	}
+	return nil
+}
+
+// Add 新增或覆蓋一條命令輸出
+func (f *Fixture) Add(command, output string, err error) {
+	entry := FixtureEntry{Command: command, Output: output}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	for i := range f.Commands {
+		if f.Commands[i].Command == command {
+			f.Commands[i] = entry
+			return
+		}
+	}
+	f.Commands = append(f.Commands, entry)
+}
+
+// Lookup 查找命令的輸出（完整比對）
+func (f *Fixture) Lookup(command string) (FixtureEntry, bool) {
+	for _, entry := range f.Commands {
+		if entry.Command == command {
+			return entry, true
+		}
+	}
+	return FixtureEntry{}, false
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+)
+
+func TestRecordingExecutorClassifiesCommands(t *testing.T) {
+	rc := &config.RuntimeConfig{Mode: "remote", DockerCommand: "docker", DockerComposeCommand: "docker compose"}
+
+	tests := []struct {
+		command  string
+		readOnly bool
+	}{
+		{"docker ps -q -a --filter name=^/api-dev$", true},
+		{"docker inspect --type container abc", true},
+		{"docker logs --tail 10 api-dev", true},
+		{"docker container inspect abc", true},
+		{"docker network ls", true},
+		{"cd /opt/app && docker compose ps -q api -a", true},
+		{"docker compose config", true},
+		{"docker create --name api-dev app:1", false},
+		{"docker rm -f api-dev", false},
+		{"docker restart api-dev", false},
+		{"docker kill --signal HUP api-dev", false},
+		{"docker network rm app_default", false},
+		{"cd /opt/app && docker compose stop api", false},
+		{"cd /opt/app && docker compose up -d api", false},
+		{"mkdir -p /tmp/dev", false},
+		{"uname -m", false},
+		{"docker ps; rm -rf /tmp/x", false},
+		{"docker inspect abc | sh", false},
+		{"docker ps $(rm -rf /tmp/x)", false},
+		{"docker ps `rm -rf /tmp/x`", false},
+		{"docker logs api-dev > /etc/passwd", false},
+		{"docker ps || rm -rf /tmp/x", false},
+		{"docker ps & rm -rf /tmp/x", false},
+		{"docker ps\nrm -rf /tmp/x", false},
+		{"cd /opt/app; rm -rf x && docker compose ps", false},
+		{"cd /opt/app && docker compose ps && rm -rf x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			fixture := NewFixture()
+			fixture.Add(tt.command, "output", nil)
+			rec := NewRecordingExecutor(nil, fixture, rc)
+
+			output, err := rec.Execute(context.Background(), tt.command)
+			if err != nil {
+				t.Fatalf("Execute: %v", err)
+			}
+			recorded := len(rec.Steps()) == 1
+			if tt.readOnly && (recorded || output != "output") {
+				t.Errorf("read-only command should be answered from the fixture, recorded=%v output=%q", recorded, output)
+			}
+			if !tt.readOnly && (!recorded || output != "") {
+				t.Errorf("mutating command should only be recorded, recorded=%v output=%q", recorded, output)
+			}
+		})
+	}
+}
+
+func TestRecordingExecutorWithReadOnly(t *testing.T) {
+	rc := &config.RuntimeConfig{Mode: "remote", DockerCommand: "docker", DockerComposeCommand: "docker compose"}
+	fixture := NewFixture()
+	fixture.Add("uname -m", "x86_64\n", nil)
+	rec := NewRecordingExecutor(nil, fixture, rc)
+
+	output, err := rec.Execute(WithReadOnly(context.Background()), "uname -m")
+	if err != nil || output != "x86_64\n" {
+		t.Fatalf("Execute = %q, %v", output, err)
+	}
+	if steps := rec.Steps(); len(steps) != 0 {
+		t.Errorf("probes marked read-only must not be recorded, got %+v", steps)
+	}
+
+	if _, err := rec.Execute(WithReadOnly(context.Background()), "cat /etc/os-release"); err == nil {
+		t.Error("read-only query missing from the fixture should fail without a host")
+	}
+}
+
+func TestRecordingExecutorRecordsSideEffects(t *testing.T) {
+	rc := &config.RuntimeConfig{Mode: "remote", DockerCommand: "docker"}
+	rec := NewRecordingExecutor(nil, NewFixture(), rc)
+	ctx := context.Background()
+
+	rec.CreateScript(ctx, "echo hi\n", "/tmp/dev/init.sh")
+	rec.UploadFileMode(ctx, "/nonexistent/config.yaml", "/tmp/dev/config.yaml", 0644)
+	tunnel, err := rec.CreateTunnel(ctx, LocalPort{Port: 2345}, TunnelTarget{Addr: "localhost:2345"})
+	if err != nil || tunnel.ListenPort() != 2345 {
+		t.Fatalf("CreateTunnel = %v, %v", tunnel, err)
+	}
+
+	steps := rec.Steps()
+	kinds := []PlanStepKind{PlanStepScript, PlanStepUpload, PlanStepTunnel}
+	if len(steps) != len(kinds) {
+		t.Fatalf("steps = %+v", steps)
+	}
+	for i, kind := range kinds {
+		if steps[i].Kind != kind {
+			t.Errorf("step %d kind = %s, want %s", i, steps[i].Kind, kind)
+		}
+	}
+	if steps[1].Detail != "/nonexistent/config.yaml → /tmp/dev/config.yaml [0644]（本地檔案不存在）" {
+		t.Errorf("upload detail = %q", steps[1].Detail)
+	}
+}
@@ -1,7 +1,11 @@
 package executor
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"os"
+	"strings"
 
 	"github.com/laysdragon/go-docker-dev-swap/internal/config"
 )
@@ -36,14 +40,17 @@ func NewRemoteExecutor(rc *config.RuntimeConfig) (*RemoteExecutor, error) {
 	}, nil
 }
 
-func (e *RemoteExecutor) Execute(command string) (string, error) {
+func (e *RemoteExecutor) Execute(ctx context.Context, command string) (string, error) {
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Command)
+	defer cancel()
+
 	// 使用 sudo wrapper 包装命令
 	wrappedCmd := e.sudoWrapper.Wrap(command)
-	return e.sshClient.Execute(wrappedCmd)
+	return e.sshClient.Execute(ctx, wrappedCmd)
 }
 
-func (e *RemoteExecutor) CreateSession() (Session, error) {
-	sshSession, err := e.sshClient.CreateSession()
+func (e *RemoteExecutor) CreateSession(ctx context.Context) (Session, error) {
+	sshSession, err := e.sshClient.CreateSession(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -53,16 +60,57 @@ func (e *RemoteExecutor) CreateSession() (Session, error) {
 	}, nil
 }
 
-func (e *RemoteExecutor) UploadFile(localPath, remotePath string) error {
-	return e.sshClient.UploadFile(localPath, remotePath)
+func (e *RemoteExecutor) UploadFile(ctx context.Context, localPath, remotePath string) error {
+	return e.UploadFileMode(ctx, localPath, remotePath, 0755)
+}
+
+func (e *RemoteExecutor) UploadFileMode(ctx context.Context, localPath, remotePath string, mode os.FileMode) error {
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Upload)
+	defer cancel()
+	return e.sshClient.UploadFile(ctx, localPath, remotePath, mode)
+}
+
+func (e *RemoteExecutor) CreateScript(ctx context.Context, script, path string) error {
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Upload)
+	defer cancel()
+	return e.sshClient.CreateScript(ctx, script, path)
 }
 
-func (e *RemoteExecutor) CreateScript(script, path string) error {
-	return e.sshClient.CreateScript(script, path)
+// ExpandScript 直接經由 SSH 執行，不套用 sudo wrapper，變數以登入使用者的環境展開
+func (e *RemoteExecutor) ExpandScript(ctx context.Context, script string) (string, error) {
+	if script == "" {
+		return script, nil
+	}
+	ctx, cancel := withTimeout(ctx, e.config.Host.Timeouts.Command)
+	defer cancel()
+
+	output, err := e.sshClient.Execute(ctx, fmt.Sprintf("echo -e \"%s\"", script))
+	if err != nil {
+		return "", fmt.Errorf("展開腳本失敗: %w", err)
+	}
+	return strings.TrimSuffix(output, "\n"), nil
 }
 
-func (e *RemoteExecutor) CreateTunnel(localPort, remotePort int) (TunnelCloser, error) {
-	return e.sshClient.CreateTunnel(localPort, remotePort)
+func (e *RemoteExecutor) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	return e.sshClient.Dial(ctx, network, addr)
+}
+
+func (e *RemoteExecutor) CreateReverseTunnel(ctx context.Context, remoteAddr, localAddr string) (TunnelCloser, error) {
+	tunnel, err := e.sshClient.CreateReverseTunnel(ctx, remoteAddr, localAddr)
+	if err != nil {
+		return nil, err
+	}
+	tunnel.SetLogConnections(e.config.Host.LogTunnelConnections)
+	return tunnel, nil
+}
+
+func (e *RemoteExecutor) CreateTunnel(ctx context.Context, local LocalPort, target TunnelTarget) (TunnelCloser, error) {
+	tunnel, err := e.sshClient.CreateTunnel(ctx, local, target)
+	if err != nil {
+		return nil, err
+	}
+	tunnel.SetLogConnections(e.config.Host.LogTunnelConnections)
+	return tunnel, nil
 }
 
 func (e *RemoteExecutor) Close() error {
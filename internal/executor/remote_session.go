@@ -1,8 +1,11 @@
 package executor
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	gossh "golang.org/x/crypto/ssh"
 )
@@ -11,7 +14,28 @@ import (
 type RemoteSession struct {
 	session     *gossh.Session
 	stdout      io.Reader
+	stderr      io.Reader
+	stdin       io.WriteCloser
 	sudoWrapper *SudoWrapper
+	stopWatch   func() bool
+
+	// PTY 模式
+	usePTY  bool
+	ptyTerm string
+	ptyRows int
+	ptyCols int
+	started bool
+}
+
+func (s *RemoteSession) RequestPTY(term string, rows, cols int) error {
+	if s.started {
+		return fmt.Errorf("必須在 Start 之前請求 PTY")
+	}
+	s.usePTY = true
+	s.ptyTerm = term
+	s.ptyRows = rows
+	s.ptyCols = cols
+	return nil
 }
 
 func (s *RemoteSession) StdoutPipe() (io.Reader, error) {
@@ -21,36 +45,114 @@ func (s *RemoteSession) StdoutPipe() (io.Reader, error) {
 	return nil, fmt.Errorf("必須先調用 Start")
 }
 
-func (s *RemoteSession) Start(command string) error {
-	// SSH 必須在 Start 之前設置 StdoutPipe
-	if s.stdout == nil {
-		stdout, err := s.session.StdoutPipe()
-		if err != nil {
-			return fmt.Errorf("獲取標準輸出失敗: %w", err)
-		}
-		s.stdout = stdout
+func (s *RemoteSession) StderrPipe() (io.Reader, error) {
+	if s.stderr != nil {
+		return s.stderr, nil
+	}
+	return nil, fmt.Errorf("必須先調用 Start")
+}
+
+func (s *RemoteSession) StdinPipe() (io.WriteCloser, error) {
+	if s.stdin != nil {
+		return s.stdin, nil
 	}
-	
-	// 使用 sudo wrapper 包裝命令
+	return nil, fmt.Errorf("必須先調用 Start")
+}
+
+func (s *RemoteSession) Start(ctx context.Context, command string) error {
+	// 使用 sudo wrapper 包裝命令（保留標準輸入）
 	wrappedCmd := command
 	if s.sudoWrapper != nil {
-		wrappedCmd = s.sudoWrapper.Wrap(command)
+		wrappedCmd = s.sudoWrapper.WrapInteractive(command)
+	}
+
+	if s.usePTY {
+		term := s.ptyTerm
+		if term == "" {
+			term = "xterm"
+		}
+		if err := s.session.RequestPty(term, s.ptyRows, s.ptyCols, gossh.TerminalModes{}); err != nil {
+			return fmt.Errorf("請求 PTY 失敗: %w", err)
+		}
+	}
+
+	// SSH 必須在 Start 之前設置所有管道
+	stdout, err := s.session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("獲取標準輸出失敗: %w", err)
 	}
-	
+	stdin, err := s.session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("獲取標準輸入失敗: %w", err)
+	}
+	s.stdout = stdout
+	s.stdin = stdin
+	if s.usePTY {
+		s.stderr = strings.NewReader("")
+	} else {
+		stderr, err := s.session.StderrPipe()
+		if err != nil {
+			return fmt.Errorf("獲取標準錯誤失敗: %w", err)
+		}
+		s.stderr = stderr
+	}
+
 	if err := s.session.Start(wrappedCmd); err != nil {
 		return fmt.Errorf("啟動命令失敗: %w", err)
 	}
+	s.started = true
+
+	// ctx 取消時關閉 SSH session，遠端命令隨 channel 關閉而結束
+	s.stopWatch = context.AfterFunc(ctx, func() {
+		s.session.Signal(gossh.SIGKILL)
+		s.session.Close()
+	})
 	return nil
 }
 
+func (s *RemoteSession) WindowChange(rows, cols int) error {
+	if !s.usePTY {
+		return fmt.Errorf("session 未使用 PTY")
+	}
+	return s.session.WindowChange(rows, cols)
+}
+
+func (s *RemoteSession) Signal(sig Signal) error {
+	if !s.started {
+		return fmt.Errorf("必須先調用 Start")
+	}
+	return s.session.Signal(gossh.Signal(sig))
+}
+
 func (s *RemoteSession) Wait() error {
-	if s.session == nil {
+	if s.session == nil || !s.started {
 		return nil
 	}
-	return s.session.Wait()
+	err := s.session.Wait()
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *gossh.ExitError
+	if errors.As(err, &exitErr) {
+		result := &ExitError{Code: exitErr.ExitStatus(), Err: err}
+		if exitErr.Signal() != "" {
+			result.Code = -1
+			result.Signal = Signal(exitErr.Signal())
+		}
+		return result
+	}
+	var missingErr *gossh.ExitMissingError
+	if errors.As(err, &missingErr) {
+		return &ExitError{Code: -1, Err: err}
+	}
+	return err
 }
 
 func (s *RemoteSession) Close() error {
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
 	if s.session != nil {
 		return s.session.Close()
 	}
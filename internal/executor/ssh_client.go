@@ -1,9 +1,9 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"log"
 	"net"
 	"os"
 	"path/filepath"
@@ -65,47 +65,131 @@ func (c *SSHClient) Close() error {
 	return c.client.Close()
 }
 
-func (c *SSHClient) CreateSession() (*ssh.Session, error) {
-	session, err := c.client.NewSession()
-	if err != nil {
-		return nil, fmt.Errorf("建立 SSH session 失敗: %w", err)
+// CreateSession 建立 SSH session，ctx 取消時放棄等待並關閉已建立的 session
+func (c *SSHClient) CreateSession(ctx context.Context) (*ssh.Session, error) {
+	type result struct {
+		session *ssh.Session
+		err     error
+	}
+	resultCh := make(chan result, 1)
+	go func() {
+		session, err := c.client.NewSession()
+		resultCh <- result{session: session, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-resultCh; r.session != nil {
+				r.session.Close()
+			}
+		}()
+		return nil, fmt.Errorf("建立 SSH session 中斷: %w", ctx.Err())
+	case r := <-resultCh:
+		if r.err != nil {
+			return nil, fmt.Errorf("建立 SSH session 失敗: %w", r.err)
+		}
+		return r.session, nil
 	}
-	return session, nil
 }
 
-func (c *SSHClient) CreateScript(script, path string) error {
-	if _, err := c.Execute(fmt.Sprintf("echo -e \"%s\" > %s", script, path)); err != nil {
-		return fmt.Errorf("建立腳本 %s 失敗: %w", path, err)
+// CreateScript 以 SFTP 寫入腳本，內容不經過遠端 shell 解析（$、引號與反斜線保持原樣）
+// 先寫入同目錄的暫存檔再 rename 取代，正在執行舊腳本的 shell 不會讀到寫到一半的內容
+func (c *SSHClient) CreateScript(ctx context.Context, script, path string) error {
+	sftpClient, err := sftp.NewClient(c.client)
+	if err != nil {
+		return fmt.Errorf("建立 SFTP 客戶端失敗: %w", err)
+	}
+	defer sftpClient.Close()
+
+	stop := context.AfterFunc(ctx, func() {
+		sftpClient.Close()
+	})
+	defer stop()
+
+	if err := sftpClient.MkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("建立遠端目錄失敗: %w", err)
 	}
 
-	if _, err := c.Execute(fmt.Sprintf("chmod +x %s", path)); err != nil {
-		return fmt.Errorf("賦予腳本 %s 執行權限失敗: %w", path, err)
+	tmp := path + ".tmp"
+	if err := writeRemoteScript(sftpClient, script, tmp); err != nil {
+		sftpClient.Remove(tmp)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("建立腳本中斷: %w", ctxErr)
+		}
+		return fmt.Errorf("寫入腳本 %s 失敗: %w", path, err)
+	}
+
+	if err := sftpClient.PosixRename(tmp, path); err != nil {
+		sftpClient.Remove(tmp)
+		return fmt.Errorf("建立腳本 %s 失敗: %w", path, err)
 	}
 	return nil
 }
 
-func (c *SSHClient) Execute(command string) (string, error) {
-	session, err := c.client.NewSession()
+// writeRemoteScript 寫入腳本內容並賦予執行權限
+func writeRemoteScript(sftpClient *sftp.Client, script, path string) error {
+	file, err := sftpClient.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
 	if err != nil {
-		return "", fmt.Errorf("建立 SSH session 失敗: %w", err)
+		return err
 	}
-	defer session.Close()
+	if _, err := file.Write([]byte(script)); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return sftpClient.Chmod(path, 0755)
+}
 
-	output, err := session.CombinedOutput(command)
+// Execute 執行遠端命令，ctx 取消時關閉 SSH session 並立即返回
+func (c *SSHClient) Execute(ctx context.Context, command string) (string, error) {
+	session, err := c.CreateSession(ctx)
 	if err != nil {
-		return string(output), fmt.Errorf("執行命令失敗: %w (%s)=>(%s)", err, command, output)
+		return "", err
 	}
+	defer session.Close()
 
-	return string(output), nil
+	var output lockedBuffer
+	session.Stdout = &output
+	session.Stderr = &output
+	if err := session.Start(command); err != nil {
+		return "", fmt.Errorf("執行命令失敗: %w (%s)", err, command)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	select {
+	case <-ctx.Done():
+		session.Signal(ssh.SIGKILL)
+		session.Close()
+		return output.String(), fmt.Errorf("執行命令中斷: %w (%s)", ctx.Err(), command)
+	case err := <-done:
+		if err != nil {
+			return output.String(), fmt.Errorf("執行命令失敗: %w (%s)=>(%s)", err, command, output.String())
+		}
+	}
+
+	return output.String(), nil
 }
 
-func (c *SSHClient) UploadFile(localPath, remotePath string) error {
+// UploadFile 透過 SFTP 上傳檔案，ctx 取消時關閉 SFTP 連線中斷傳輸
+func (c *SSHClient) UploadFile(ctx context.Context, localPath, remotePath string, mode os.FileMode) error {
 	sftpClient, err := sftp.NewClient(c.client)
 	if err != nil {
 		return fmt.Errorf("建立 SFTP 客戶端失敗: %w", err)
 	}
 	defer sftpClient.Close()
 
+	stop := context.AfterFunc(ctx, func() {
+		sftpClient.Close()
+	})
+	defer stop()
+
 	// 建立遠端目錄
 	remoteDir := filepath.Dir(remotePath)
 	if err := sftpClient.MkdirAll(remoteDir); err != nil {
@@ -135,70 +219,69 @@ func (c *SSHClient) UploadFile(localPath, remotePath string) error {
 	defer remoteFile.Close()
 
 	// 複製檔案內容
-	if _, err := io.Copy(remoteFile, localFile); err != nil {
+	if _, err := io.Copy(remoteFile, &contextReader{ctx: ctx, reader: localFile}); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("上傳檔案中斷: %w", ctxErr)
+		}
 		return fmt.Errorf("上傳檔案失敗: %w", err)
 	}
 
-	// 設定執行權限
-	if err := sftpClient.Chmod(remotePath, 0755); err != nil {
+	// 設定檔案權限
+	if err := sftpClient.Chmod(remotePath, mode); err != nil {
 		return fmt.Errorf("設定檔案權限失敗: %w", err)
 	}
 
 	return nil
 }
 
-type Tunnel struct {
-	listener net.Listener
-	client   *ssh.Client
-}
-
-func (c *SSHClient) CreateTunnel(localPort, remotePort int) (*Tunnel, error) {
-	listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", localPort))
-	if err != nil {
-		return nil, fmt.Errorf("建立本地監聽失敗: %w", err)
+// Dial 經由 SSH 連線在遠端建立 tcp 或 unix socket 連線
+func (c *SSHClient) Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
 	}
-
-	tunnel := &Tunnel{
-		listener: listener,
-		client:   c.client,
-	}
-
+	resultCh := make(chan result, 1)
 	go func() {
-		for {
-			localConn, err := listener.Accept()
-			if err != nil {
-				return
+		conn, err := c.client.Dial(network, addr)
+		resultCh <- result{conn: conn, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		go func() {
+			if r := <-resultCh; r.conn != nil {
+				r.conn.Close()
 			}
-			log.Printf("接受本地連接: %s", localConn.RemoteAddr().String())
-
-			go func(local net.Conn) {
-				defer local.Close()
-				defer log.Printf("關閉本地連接: %s", local.RemoteAddr().String())
-
-				remote, err := c.client.Dial("tcp", fmt.Sprintf("localhost:%d", remotePort))
-				if err != nil {
-					return
-				}
-				defer remote.Close()
-
-				// 雙向複製數據
-				done := make(chan struct{}, 2)
-				go func() {
-					io.Copy(remote, local)
-					done <- struct{}{}
-				}()
-				go func() {
-					io.Copy(local, remote)
-					done <- struct{}{}
-				}()
-				<-done
-			}(localConn)
+		}()
+		return nil, fmt.Errorf("遠端連線 %s 中斷: %w", addr, ctx.Err())
+	case r := <-resultCh:
+		if r.err != nil {
+			return nil, fmt.Errorf("遠端連線 %s 失敗: %w", addr, r.err)
 		}
-	}()
+		return r.conn, nil
+	}
+}
 
-	return tunnel, nil
+// CreateTunnel 建立本地到遠端的端口轉發，ctx 結束時自動關閉
+func (c *SSHClient) CreateTunnel(ctx context.Context, local LocalPort, target TunnelTarget) (*Tunnel, error) {
+	dial := target.Dial
+	if dial == nil {
+		dial = func(ctx context.Context) (net.Conn, error) {
+			return c.Dial(ctx, "tcp", target.Addr)
+		}
+	}
+	return NewTunnel(ctx, local, dial)
 }
 
-func (t *Tunnel) Close() error {
-	return t.listener.Close()
+// CreateReverseTunnel 請求遠端主機在 remoteAddr 監聽（tcpip-forward），並將連線轉發到本地 localAddr
+// ctx 結束或 Close 時取消遠端監聽
+func (c *SSHClient) CreateReverseTunnel(ctx context.Context, remoteAddr, localAddr string) (*Tunnel, error) {
+	listener, err := c.client.Listen("tcp", remoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("建立遠端監聽失敗（需要 sshd 允許 TCP 轉發）: %w", err)
+	}
+	return newTunnelFromListener(ctx, listener, func(ctx context.Context) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", localAddr)
+	}), nil
 }
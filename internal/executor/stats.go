@@ -0,0 +1,164 @@
+package executor
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// dialErrorLogInterval 相同的連線錯誤在此間隔內只輸出一次，避免 IDE 重試時洗版
+const dialErrorLogInterval = 10 * time.Second
+
+// TunnelStats tunnel 或代理的連線統計快照
+type TunnelStats struct {
+	Active      int64     // 目前正在轉送的連線數
+	Total       int64     // 累計接受的連線數
+	BytesOut    int64     // 本地 → 目標的位元組數
+	BytesIn     int64     // 目標 → 本地的位元組數
+	DialErrors  int64     // 連接目標失敗的次數
+	LastError   string    // 最近一次連接失敗的原因
+	LastErrorAt time.Time // 最近一次連接失敗的時間
+}
+
+// ConnStats 連線統計計數器，可並行更新
+type ConnStats struct {
+	active     atomic.Int64
+	total      atomic.Int64
+	bytesOut   atomic.Int64
+	bytesIn    atomic.Int64
+	dialErrors atomic.Int64
+
+	logConnections atomic.Bool
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+	lastLogged  time.Time
+}
+
+// Snapshot 返回目前的統計數據
+func (s *ConnStats) Snapshot() TunnelStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return TunnelStats{
+		Active:      s.active.Load(),
+		Total:       s.total.Load(),
+		BytesOut:    s.bytesOut.Load(),
+		BytesIn:     s.bytesIn.Load(),
+		DialErrors:  s.dialErrors.Load(),
+		LastError:   s.lastError,
+		LastErrorAt: s.lastErrorAt,
+	}
+}
+
+// SetLogConnections 設定是否在工作日誌輸出每個連線的建立與關閉
+func (s *ConnStats) SetLogConnections(enabled bool) {
+	s.logConnections.Store(enabled)
+}
+
+// Opened 記錄新連線，返回連線結束時需呼叫的函式
+func (s *ConnStats) Opened(conn net.Conn) func() {
+	s.total.Add(1)
+	s.active.Add(1)
+	if s.logConnections.Load() {
+		log.Printf("接受連接: %s", conn.RemoteAddr())
+	}
+	return func() {
+		s.active.Add(-1)
+		if s.logConnections.Load() {
+			log.Printf("關閉連接: %s", conn.RemoteAddr())
+		}
+	}
+}
+
+// DialFailed 記錄連接目標失敗，返回附帶原因提示的說明
+// 原因改變或距離上次輸出超過 dialErrorLogInterval 時寫入工作日誌
+func (s *ConnStats) DialFailed(name string, err error) string {
+	s.dialErrors.Add(1)
+	reason := DescribeDialError(err)
+
+	s.mu.Lock()
+	shouldLog := reason != s.lastError || time.Since(s.lastLogged) >= dialErrorLogInterval
+	s.lastError = reason
+	s.lastErrorAt = time.Now()
+	if shouldLog {
+		s.lastLogged = s.lastErrorAt
+	}
+	s.mu.Unlock()
+
+	if shouldLog {
+		log.Printf("%s 連接目標失敗: %s", name, reason)
+	}
+	return reason
+}
+
+// Pipe 雙向複製資料並累計位元組數，任一方向結束時返回
+func (s *ConnStats) Pipe(local io.ReadWriter, remote io.ReadWriter) {
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(&countingWriter{writer: remote, counter: &s.bytesOut}, local)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(&countingWriter{writer: local, counter: &s.bytesIn}, remote)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// countingWriter 寫入時累計位元組數
+type countingWriter struct {
+	writer  io.Writer
+	counter *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.counter.Add(int64(n))
+	return n, err
+}
+
+// DescribeDialError 為常見的連線錯誤附上原因提示
+func DescribeDialError(err error) string {
+	if hint := dialErrorHint(err); hint != "" {
+		return hint + ": " + err.Error()
+	}
+	return err.Error()
+}
+
+func dialErrorHint(err error) string {
+	var channelErr *ssh.OpenChannelError
+	if errors.As(err, &channelErr) {
+		switch {
+		case channelErr.Reason == ssh.Prohibited:
+			return "sshd 不允許 TCP 轉發（請檢查 AllowTcpForwarding）"
+		case strings.Contains(strings.ToLower(channelErr.Message), "refused"):
+			return "connection refused：目標端口尚未監聽（例如 debugger 尚未啟動）"
+		case channelErr.Reason == ssh.ConnectionFailed:
+			return "遠端主機無法連接目標"
+		}
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return "connection refused：目標端口尚未監聽（例如 debugger 尚未啟動）"
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return "無法解析主機名稱"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "連線逾時：目標無法到達"
+	}
+	return ""
+}
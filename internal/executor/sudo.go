@@ -42,6 +42,23 @@ func (w *SudoWrapper) Wrap(command string) string {
 	return fmt.Sprintf("sudo bash -c '%s'", escapedCmd)
 }
 
+// WrapInteractive 包装需要保留标准输入的命令（用于 Session）
+// Wrap 的 echo 管道会占用命令的标准输入；而 sudo -S 只在需要验证时才读取密码，
+// NOPASSWD 或凭证仍在有效期内时，预先写入的密码会进入命令的标准输入（例如被交互式 shell 当作命令执行）。
+// 因此先以 sudo -S -v 单独验证密码并缓存凭证，再以 sudo -n 执行命令，密码不会经过命令的标准输入。
+// 两次 sudo 必须由同一个 shell 启动（不使用 exec）：没有 TTY 时 sudo 以父进程记录凭证
+func (w *SudoWrapper) WrapInteractive(command string) string {
+	if !w.enabled {
+		return command
+	}
+
+	escapedCmd := strings.ReplaceAll(command, "'", "'\\''")
+	if w.password != "" {
+		return fmt.Sprintf("echo '%s' | sudo -S -p '' -v && sudo -n bash -c '%s'; exit $?", w.password, escapedCmd)
+	}
+	return fmt.Sprintf("sudo bash -c '%s'", escapedCmd)
+}
+
 // WrapMultiple 包装多个命令参数组成的命令
 // 例如: WrapMultiple("docker", "ps", "-a") -> sudo bash -c 'docker ps -a'
 func (w *SudoWrapper) WrapMultiple(parts ...string) string {
@@ -0,0 +1,136 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+)
+
+// DialFunc 建立 tunnel 另一端的連線
+type DialFunc func(ctx context.Context) (net.Conn, error)
+
+// Tunnel 本地端口轉發：接受本地連線並透過 dial 建立的連線雙向轉送
+type Tunnel struct {
+	listener net.Listener
+	dial     DialFunc
+	ctx      context.Context
+	stop     func() bool
+
+	stats ConnStats
+}
+
+// nextFreeAttempts next_free 策略最多嘗試的端口數
+const nextFreeAttempts = 100
+
+// LocalPort tunnel 的本地監聽端口與分配策略
+type LocalPort struct {
+	Port   int
+	Policy string // config.PortPolicyFixed / PortPolicyNextFree / PortPolicyEphemeral，空字串視為 fixed
+}
+
+// NewTunnel 依 local 的策略在本地監聽並轉發到 dial 建立的連線，ctx 結束時自動關閉
+func NewTunnel(ctx context.Context, local LocalPort, dial DialFunc) (*Tunnel, error) {
+	listener, err := ListenLocal(local)
+	if err != nil {
+		return nil, err
+	}
+	return newTunnelFromListener(ctx, listener, dial), nil
+}
+
+// ListenLocal 依端口分配策略在 localhost 上監聽
+// 端口由作業系統檢查，同一台電腦上同時執行的多個 session 不會拿到相同端口
+func ListenLocal(local LocalPort) (net.Listener, error) {
+	switch local.Policy {
+	case config.PortPolicyEphemeral:
+		listener, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			return nil, fmt.Errorf("建立本地監聽失敗: %w", err)
+		}
+		return listener, nil
+	case config.PortPolicyNextFree:
+		var lastErr error
+		for port := local.Port; port < local.Port+nextFreeAttempts && port <= 65535; port++ {
+			listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", port))
+			if err == nil {
+				return listener, nil
+			}
+			lastErr = err
+		}
+		return nil, fmt.Errorf("端口 %d 起的 %d 個端口都無法使用: %w", local.Port, nextFreeAttempts, lastErr)
+	default:
+		listener, err := net.Listen("tcp", fmt.Sprintf("localhost:%d", local.Port))
+		if err != nil {
+			return nil, fmt.Errorf("建立本地監聽失敗（端口 %d 可能已被佔用，可改用 next_free 或 ephemeral 策略）: %w", local.Port, err)
+		}
+		return listener, nil
+	}
+}
+
+// newTunnelFromListener 以既有的 listener 建立 tunnel，例如 SSH 遠端端口轉發的 listener
+func newTunnelFromListener(ctx context.Context, listener net.Listener, dial DialFunc) *Tunnel {
+	tunnel := &Tunnel{
+		listener: listener,
+		dial:     dial,
+		ctx:      ctx,
+	}
+	tunnel.stop = context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+
+	go tunnel.serve()
+	return tunnel
+}
+
+// Addr 返回本地監聽地址
+func (t *Tunnel) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+// ListenPort 返回實際監聽的端口
+func (t *Tunnel) ListenPort() int {
+	if addr, ok := t.listener.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// Stats 返回連線統計
+func (t *Tunnel) Stats() TunnelStats {
+	return t.stats.Snapshot()
+}
+
+// SetLogConnections 設定是否在工作日誌輸出每個連線的建立與關閉
+func (t *Tunnel) SetLogConnections(enabled bool) {
+	t.stats.SetLogConnections(enabled)
+}
+
+func (t *Tunnel) serve() {
+	name := fmt.Sprintf("Tunnel %s", t.listener.Addr())
+	for {
+		localConn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(local net.Conn) {
+			defer local.Close()
+			defer t.stats.Opened(local)()
+
+			remote, err := t.dial(t.ctx)
+			if err != nil {
+				t.stats.DialFailed(name, err)
+				return
+			}
+			defer remote.Close()
+
+			t.stats.Pipe(local, remote)
+		}(localConn)
+	}
+}
+
+func (t *Tunnel) Close() error {
+	t.stop()
+	return t.listener.Close()
+}
@@ -1,8 +1,61 @@
 package executor
 
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
 // noopCloser 是一個空的 closer，用於本地模式的 tunnel
-type noopCloser struct{}
+type noopCloser struct {
+	port int
+}
 
 func (n *noopCloser) Close() error {
 	return nil
 }
+
+func (n *noopCloser) ListenPort() int {
+	return n.port
+}
+
+// withTimeout 為 ctx 套用操作逾時，timeout <= 0 時不設限
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextReader 在每次讀取前檢查 ctx，讓 io.Copy 可以被取消
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// lockedBuffer 可同時作為 stdout 與 stderr 使用的 buffer
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
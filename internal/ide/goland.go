@@ -0,0 +1,52 @@
+package ide
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// golandRunDir GoLand 可共享的 run configuration 目錄（相對於專案根目錄）
+const golandRunDir = ".run"
+
+// WriteGoLand 寫入 GoLand 的 Go Remote run configuration（.run/<名稱>.run.xml）
+// GoLand 會依專案內的檔案自動對應 dlv 回報的路徑，不需要 substitutePath
+func WriteGoLand(workspace string, target Target) (string, error) {
+	name := xmlEscape(target.Name)
+	content := fmt.Sprintf(`<component name="ProjectRunConfigurationManager">
+  <configuration default="false" name="%s" type="GoRemoteDebugConfigurationType" factoryName="Go Remote" host="%s" port="%d">
+    <option name="disconnectOption" value="LEAVE" />
+    <disconnect value="LEAVE" />
+    <method v="2" />
+  </configuration>
+</component>
+`, name, xmlEscape(target.Host), target.Port)
+
+	path := filepath.Join(workspace, golandRunDir, fileName(target.Name)+".run.xml")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("建立目錄失敗: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("寫入 %s 失敗: %w", path, err)
+	}
+	return path, nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// fileName 將設定名稱中不適合作為檔名的字元替換為 '_'
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ', '@':
+			return '_'
+		}
+		return r
+	}, name)
+}
@@ -0,0 +1,39 @@
+// Package ide 產生連接開發容器 debugger 的 IDE 設定（VS Code launch.json、GoLand run configuration）
+package ide
+
+import "github.com/laysdragon/go-docker-dev-swap/internal/config"
+
+const (
+	EditorVSCode = "vscode"
+	EditorGoLand = "goland"
+)
+
+// Editors 支援的編輯器
+var Editors = []string{EditorVSCode, EditorGoLand}
+
+// Target 一組 debugger 連接設定
+type Target struct {
+	Name          string // 設定名稱，重複產生時以此更新既有設定
+	Host          string
+	Port          int
+	Mode          string // config.DlvModeAPI 或 config.DlvModeDAP
+	Program       string // 容器內執行檔路徑（DAP launch 使用）
+	Substitutions []Substitution
+}
+
+// Write 在 workspace 寫入指定編輯器的設定，返回寫入的檔案路徑
+// 第二個返回值為 false 表示該編輯器不支援此模式而略過
+func Write(editor, workspace string, target Target) (string, bool, error) {
+	switch editor {
+	case EditorVSCode:
+		path, err := WriteVSCode(workspace, target)
+		return path, true, err
+	case EditorGoLand:
+		if target.Mode == config.DlvModeDAP {
+			return "", false, nil
+		}
+		path, err := WriteGoLand(workspace, target)
+		return path, true, err
+	}
+	return "", false, nil
+}
@@ -0,0 +1,173 @@
+package ide
+
+import (
+	"bufio"
+	"debug/buildinfo"
+	"debug/dwarf"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Substitution 原始碼路徑對應：From 為本地路徑，To 為執行檔中（dlv 回報）的路徑
+type Substitution struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// SourceInfo 從執行檔取得的原始碼位置資訊
+type SourceInfo struct {
+	ModulePath string // 主模組路徑
+	BuildRoot  string // 編譯時主模組的根目錄（-trimpath 編譯時為模組路徑）
+	Workspace  string // 本地主模組根目錄（go.mod 所在目錄）
+}
+
+// Substitutions 返回本地與編譯路徑不同時需要的對應，相同時返回空
+func (s *SourceInfo) Substitutions() []Substitution {
+	if s.BuildRoot == "" || s.BuildRoot == s.Workspace {
+		return nil
+	}
+	return []Substitution{{From: s.Workspace, To: s.BuildRoot}}
+}
+
+// ReadSourceInfo 讀取執行檔內嵌的建置資訊（debug/buildinfo）與 DWARF 行號表中的檔案路徑，
+// 推算編譯時主模組的根目錄，並從 searchFrom 往上尋找宣告相同模組的本地 go.mod
+func ReadSourceInfo(binary, searchFrom string) (*SourceInfo, error) {
+	info, err := buildinfo.ReadFile(binary)
+	if err != nil {
+		return nil, fmt.Errorf("讀取建置資訊失敗: %w", err)
+	}
+	if info.Main.Path == "" || info.Main.Path == "command-line-arguments" {
+		return nil, fmt.Errorf("執行檔 %s 未包含模組資訊（請在模組內以 go build 編譯）", binary)
+	}
+
+	src := &SourceInfo{ModulePath: info.Main.Path}
+	if root, err := FindModuleRoot(searchFrom, info.Main.Path); err == nil {
+		src.Workspace = root
+	} else {
+		return nil, err
+	}
+
+	root, err := buildRoot(binary, info.Main.Path, info.Path)
+	if err != nil {
+		return nil, err
+	}
+	src.BuildRoot = root
+	return src, nil
+}
+
+// buildRoot 依主模組各套件函式宣告所在的檔案（DW_AT_decl_file）推算編譯時的模組根目錄
+// 行號表也包含被內聯的其他套件檔案，因此只採用屬於該套件的函式，並以出現次數最多的結果為準
+func buildRoot(binary, modulePath, mainPath string) (string, error) {
+	f, err := elf.Open(binary)
+	if err != nil {
+		return "", fmt.Errorf("開啟執行檔失敗: %w", err)
+	}
+	defer f.Close()
+
+	data, err := f.DWARF()
+	if err != nil {
+		return "", fmt.Errorf("讀取 DWARF 失敗（編譯時可能使用了 -ldflags=-w）: %w", err)
+	}
+
+	var (
+		votes    = make(map[string]int)
+		files    []*dwarf.LineFile
+		funcPkg  string // 目前編譯單元的函式名稱前綴
+		rel      string // 目前套件相對於模組根目錄的路徑
+		inModule bool
+	)
+	reader := data.Reader()
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return "", fmt.Errorf("讀取 DWARF 失敗: %w", err)
+		}
+		if entry == nil {
+			break
+		}
+
+		switch entry.Tag {
+		case dwarf.TagCompileUnit:
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			pkg := name
+			if name == "main" {
+				pkg = mainPath
+			}
+			inModule = pkg == modulePath || strings.HasPrefix(pkg, modulePath+"/")
+			if !inModule {
+				reader.SkipChildren()
+				continue
+			}
+			funcPkg = name + "."
+			rel = strings.TrimPrefix(pkg, modulePath)
+			files = nil
+			if lines, err := data.LineReader(entry); err == nil && lines != nil {
+				files = lines.Files()
+			}
+		case dwarf.TagSubprogram:
+			if !inModule {
+				continue
+			}
+			name, _ := entry.Val(dwarf.AttrName).(string)
+			idx, ok := entry.Val(dwarf.AttrDeclFile).(int64)
+			if !strings.HasPrefix(name, funcPkg) || !ok || idx < 0 || int(idx) >= len(files) || files[idx] == nil {
+				continue
+			}
+			dir := path.Dir(filepath.ToSlash(files[idx].Name))
+			if strings.HasSuffix(dir, rel) {
+				votes[strings.TrimSuffix(dir, rel)]++
+			}
+		}
+	}
+
+	best, count := "", 0
+	for root, n := range votes {
+		if n > count {
+			best, count = root, n
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("無法從 DWARF 找到模組 %s 的原始碼路徑", modulePath)
+	}
+	return best, nil
+}
+
+// FindModuleRoot 從 start 往上尋找宣告 modulePath 的 go.mod，返回其所在目錄
+func FindModuleRoot(start, modulePath string) (string, error) {
+	dir, err := filepath.Abs(start)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if moduleName(filepath.Join(dir, "go.mod")) == modulePath {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", fmt.Errorf("在 %s 及其上層目錄找不到模組 %s 的 go.mod", start, modulePath)
+		}
+		dir = parent
+	}
+}
+
+// moduleName 返回 go.mod 宣告的模組路徑，讀取失敗時返回空字串
+func moduleName(gomod string) string {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if name, ok := strings.CutPrefix(line, "module"); ok {
+			return strings.Trim(strings.TrimSpace(name), `"`)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package ide
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+)
+
+// vscodeLaunchPath launch.json 相對於 workspace 的位置
+const vscodeLaunchPath = ".vscode/launch.json"
+
+// WriteVSCode 新增或更新 .vscode/launch.json 中名稱為 target.Name 的設定，其他設定保持不變
+// launch.json 允許註解與結尾逗號，更新後的檔案不會保留註解
+func WriteVSCode(workspace string, target Target) (string, error) {
+	path := filepath.Join(workspace, vscodeLaunchPath)
+
+	launch := map[string]any{"version": "0.2.0"}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(stripJSONC(data), &launch); err != nil {
+			return "", fmt.Errorf("解析 %s 失敗: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return "", fmt.Errorf("讀取 %s 失敗: %w", path, err)
+	}
+
+	configurations, _ := launch["configurations"].([]any)
+	entry := vscodeConfiguration(workspace, target)
+	replaced := false
+	for i, existing := range configurations {
+		if cfg, ok := existing.(map[string]any); ok && cfg["name"] == target.Name {
+			configurations[i] = entry
+			replaced = true
+		}
+	}
+	if !replaced {
+		configurations = append(configurations, entry)
+	}
+	launch["configurations"] = configurations
+
+	out, err := json.MarshalIndent(launch, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("編碼 launch.json 失敗: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("建立目錄失敗: %w", err)
+	}
+	if err := os.WriteFile(path, append(out, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("寫入 %s 失敗: %w", path, err)
+	}
+	return path, nil
+}
+
+// vscodeLaunchConfig vscode-go 的 launch 設定
+type vscodeLaunchConfig struct {
+	Name           string         `json:"name"`
+	Type           string         `json:"type"`
+	Request        string         `json:"request"`
+	Mode           string         `json:"mode"`
+	DebugAdapter   string         `json:"debugAdapter,omitempty"`
+	Program        string         `json:"program,omitempty"`
+	Host           string         `json:"host"`
+	Port           int            `json:"port"`
+	SubstitutePath []Substitution `json:"substitutePath,omitempty"`
+}
+
+func vscodeConfiguration(workspace string, target Target) vscodeLaunchConfig {
+	entry := vscodeLaunchConfig{
+		Name:    target.Name,
+		Type:    "go",
+		Request: "attach",
+		Mode:    "remote",
+		Host:    target.Host,
+		Port:    target.Port,
+	}
+	if target.Mode == config.DlvModeDAP {
+		// dlv dap 由編輯器送出 launch 請求啟動容器內的執行檔
+		entry.Request = "launch"
+		entry.Mode = "exec"
+		entry.DebugAdapter = "dlv-dap"
+		entry.Program = target.Program
+	}
+
+	for _, sub := range target.Substitutions {
+		if sub.From == workspace {
+			sub.From = "${workspaceFolder}"
+		}
+		entry.SubstitutePath = append(entry.SubstitutePath, sub)
+	}
+	return entry
+}
+
+// stripJSONC 移除 JSON 中的 // 與 /* */ 註解以及結尾逗號（字串內容不受影響）
+func stripJSONC(data []byte) []byte {
+	var out bytes.Buffer
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out.WriteByte(c)
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			out.WriteByte(c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			out.WriteByte('\n')
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			i++
+		case c == ',':
+			// 下一個非空白字元為 } 或 ] 時略過結尾逗號
+			j := i + 1
+			for j < len(data) && (data[j] == ' ' || data[j] == '\t' || data[j] == '\n' || data[j] == '\r') {
+				j++
+			}
+			if j < len(data) && (data[j] == '}' || data[j] == ']') {
+				continue
+			}
+			out.WriteByte(c)
+		default:
+			out.WriteByte(c)
+		}
+	}
+	return out.Bytes()
+}
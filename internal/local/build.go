@@ -0,0 +1,46 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BuildResult 一次編譯的結果
+type BuildResult struct {
+	Output   string // 編譯命令的標準輸出與標準錯誤
+	Duration time.Duration
+}
+
+// RunBuild 在 dir 以 sh -c 執行編譯命令，env 會附加在目前的環境變數之後
+// 命令以非零狀態結束時返回錯誤，編譯器的輸出保留在結果中
+func RunBuild(ctx context.Context, command, dir string, env map[string]string) (*BuildResult, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
+	// 取消時不等待 sh 衍生的子程序關閉輸出
+	cmd.WaitDelay = 2 * time.Second
+
+	start := time.Now()
+	output, err := cmd.CombinedOutput()
+	result := &BuildResult{
+		Output:   strings.TrimRight(string(output), "\n"),
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		return result, fmt.Errorf("編譯失敗: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package local
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// GoModule 本地 Go 模組
+type GoModule struct {
+	Root      string // go.mod 所在目錄
+	GoVersion string // toolchain 指令的版本，沒有時為 go 指令的版本，例如 1.23.4
+}
+
+// FindModule 從 dir 往上尋找 go.mod 並讀取 Go 版本
+func FindModule(dir string) (*GoModule, error) {
+	for current := dir; ; {
+		file, err := os.Open(filepath.Join(current, "go.mod"))
+		if err == nil {
+			defer file.Close()
+			module := &GoModule{Root: current}
+			var toolchain string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) < 2 {
+					continue
+				}
+				switch fields[0] {
+				case "go":
+					module.GoVersion = fields[1]
+				case "toolchain":
+					toolchain = strings.TrimPrefix(fields[1], "go")
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				return nil, fmt.Errorf("讀取 %s 失敗: %w", filepath.Join(current, "go.mod"), err)
+			}
+			if toolchain != "" && toolchain != "local" {
+				module.GoVersion = toolchain
+			}
+			return module, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("讀取 go.mod 失敗: %w", err)
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return nil, fmt.Errorf("%s 不在 Go 模組中（找不到 go.mod）", dir)
+		}
+		current = parent
+	}
+}
+
+// VCSState 讀取 dir 所在 git 工作目錄的 revision，以及是否有未提交的變更（與 go build 的 vcs.modified 相同，包含未追蹤的檔案）
+// 不在 git 儲存庫中或沒有 git 指令時返回空字串
+func VCSState(ctx context.Context, dir string) (revision string, modified bool) {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", false
+	}
+	revision = strings.TrimSpace(string(out))
+
+	out, err = exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain").Output()
+	return revision, err == nil && len(strings.TrimSpace(string(out))) > 0
+}
@@ -0,0 +1,39 @@
+package local
+
+import "strings"
+
+// Platform 目標容器的平台（GOOS / GOARCH 命名），用於準備 dlv 與檢查執行檔
+type Platform struct {
+	GOOS   string
+	GOARCH string
+	GOARM  string // GOARCH=arm 時的版本，例如 7
+	Libc   string // glibc / musl / unknown
+}
+
+func (p Platform) String() string {
+	s := p.GOOS + "/" + p.GOARCH
+	if p.GOARM != "" {
+		s += "/v" + p.GOARM
+	}
+	return s
+}
+
+// NewPlatform 將 docker 回報的 os / architecture / variant 轉換為 Go 的平台命名
+func NewPlatform(goos, arch, variant, libc string) Platform {
+	p := Platform{GOOS: goos, GOARCH: arch, Libc: libc}
+	switch arch {
+	case "x86_64":
+		p.GOARCH = "amd64"
+	case "aarch64":
+		p.GOARCH = "arm64"
+	case "i386", "i686":
+		p.GOARCH = "386"
+	}
+	if p.GOARCH == "arm" {
+		p.GOARM = strings.TrimPrefix(variant, "v")
+		if p.GOARM == "" {
+			p.GOARM = "7"
+		}
+	}
+	return p
+}
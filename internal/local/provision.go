@@ -0,0 +1,313 @@
+package local
+
+import (
+	"context"
+	"debug/buildinfo"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// delveModule Delve 的模組路徑
+const delveModule = "github.com/go-delve/delve"
+
+// DlvRequest 準備 dlv 的條件
+type DlvRequest struct {
+	ConfigPath   string   // dlv_config.local_path，指定時直接使用
+	Platform     Platform // 目標容器平台
+	TargetBinary string   // 要調試的本地執行檔，用於檢查 Go 版本
+}
+
+// DlvBinary 準備好的 dlv
+type DlvBinary struct {
+	Path   string
+	Source string // 來源說明，用於日誌
+}
+
+// ProvisionDlv 為目標平台準備可執行的 dlv，依序嘗試：
+//  1. dlv_config.local_path
+//  2. 本地 PATH 中架構相符且可在目標 libc 上執行的 dlv（靜態連結，或目標為 glibc）
+//  3. 快取中為該平台編譯過的 dlv
+//  4. 以 CGO_ENABLED=0 從模組快取或 GOPATH 中的 Delve 原始碼交叉編譯靜態的 dlv，並存入快取
+//
+// 都無法取得時返回錯誤，呼叫端可改用容器內的 dlv
+func ProvisionDlv(ctx context.Context, req DlvRequest) (*DlvBinary, error) {
+	if req.ConfigPath != "" {
+		if _, err := os.Stat(req.ConfigPath); err != nil {
+			return nil, fmt.Errorf("配置的 dlv 路徑不存在: %s", req.ConfigPath)
+		}
+		if err := checkDlvCompatible(req.ConfigPath, req.Platform); err != nil {
+			log.Printf("警告: 配置的 dlv 可能無法在目標容器執行: %v", err)
+		}
+		warnDlvGoVersion(req.ConfigPath, "", req.TargetBinary)
+		return &DlvBinary{Path: req.ConfigPath, Source: "dlv_config.local_path"}, nil
+	}
+
+	if path, err := FindDlv(""); err == nil && path != "" {
+		err := checkDlvCompatible(path, req.Platform)
+		if err == nil {
+			warnDlvGoVersion(path, "", req.TargetBinary)
+			return &DlvBinary{Path: path, Source: "本地 PATH"}, nil
+		}
+		log.Printf("本地 dlv 不適用於目標平台 %s: %v", req.Platform, err)
+	}
+
+	src, err := findDelveSource()
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := dlvCachePath(src.version, req.Platform)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(cached); err == nil {
+		warnDlvGoVersion(cached, src.dir, req.TargetBinary)
+		return &DlvBinary{Path: cached, Source: fmt.Sprintf("快取（Delve %s，%s）", src.version, req.Platform)}, nil
+	}
+
+	log.Printf("為 %s 編譯 Delve %s（%s）...", req.Platform, src.version, src.dir)
+	if err := buildDlv(ctx, src.dir, cached, req.Platform); err != nil {
+		return nil, err
+	}
+	warnDlvGoVersion(cached, src.dir, req.TargetBinary)
+	return &DlvBinary{Path: cached, Source: fmt.Sprintf("編譯（Delve %s，%s）", src.version, req.Platform)}, nil
+}
+
+// elfMachines ELF machine 對應的 GOARCH
+var elfMachines = map[elf.Machine]string{
+	elf.EM_X86_64:  "amd64",
+	elf.EM_AARCH64: "arm64",
+	elf.EM_ARM:     "arm",
+	elf.EM_386:     "386",
+	elf.EM_PPC64:   "ppc64le",
+	elf.EM_S390:    "s390x",
+	elf.EM_RISCV:   "riscv64",
+}
+
+// checkDlvCompatible 檢查 dlv 的架構與連結方式是否能在目標平台執行
+func checkDlvCompatible(path string, platform Platform) error {
+	f, err := elf.Open(path)
+	if err != nil {
+		return fmt.Errorf("無法讀取 %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if arch := elfMachines[f.Machine]; platform.GOARCH != "" && arch != platform.GOARCH {
+		return fmt.Errorf("架構為 %s，目標為 %s", arch, platform.GOARCH)
+	}
+	if interp := elfInterpreter(f); interp != "" && platform.Libc != "glibc" {
+		return fmt.Errorf("動態連結 %s，目標容器 libc 為 %s", interp, platform.Libc)
+	}
+	return nil
+}
+
+// elfInterpreter 返回動態連結器路徑，靜態連結時返回空字串
+func elfInterpreter(f *elf.File) string {
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_INTERP {
+			data := make([]byte, prog.Filesz)
+			if _, err := prog.ReadAt(data, 0); err != nil {
+				return "PT_INTERP"
+			}
+			return strings.TrimRight(string(data), "\x00")
+		}
+	}
+	return ""
+}
+
+// delveSource 本地的 Delve 原始碼
+type delveSource struct {
+	dir     string
+	version string
+}
+
+// findDelveSource 在模組快取（取最新版本）或 GOPATH 中尋找 Delve 原始碼
+func findDelveSource() (*delveSource, error) {
+	modCache, gopath := goEnv("GOMODCACHE"), goEnv("GOPATH")
+
+	if modCache != "" {
+		dirs, _ := filepath.Glob(filepath.Join(modCache, "github.com", "go-delve", "delve@v*"))
+		sort.Slice(dirs, func(i, j int) bool {
+			return compareVersions(versionOf(dirs[i]), versionOf(dirs[j])) > 0
+		})
+		for _, dir := range dirs {
+			if _, err := os.Stat(filepath.Join(dir, "cmd", "dlv")); err == nil {
+				return &delveSource{dir: dir, version: versionOf(dir)}, nil
+			}
+		}
+	}
+
+	for _, root := range filepath.SplitList(gopath) {
+		dir := filepath.Join(root, "src", "github.com", "go-delve", "delve")
+		if _, err := os.Stat(filepath.Join(dir, "cmd", "dlv")); err == nil {
+			return &delveSource{dir: dir, version: "gopath"}, nil
+		}
+	}
+
+	return nil, errors.New("找不到 Delve 原始碼，可執行 `go mod download github.com/go-delve/delve@latest` 後重試，或設定 dlv_config.local_path")
+}
+
+// dlvCachePath 返回指定 Delve 版本與平台的快取路徑：<快取目錄>/docker-dev-swap/dlv/<版本>/<平台>/dlv
+func dlvCachePath(version string, platform Platform) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("無法取得使用者快取目錄: %w", err)
+	}
+	key := platform.GOOS + "_" + platform.GOARCH
+	if platform.GOARM != "" {
+		key += "_v" + platform.GOARM
+	}
+	return filepath.Join(cacheDir, "docker-dev-swap", "dlv", version, key, "dlv"), nil
+}
+
+// buildDlv 以 CGO_ENABLED=0 交叉編譯靜態的 dlv，先寫入暫存檔再改名，避免中斷時留下不完整的快取
+func buildDlv(ctx context.Context, srcDir, output string, platform Platform) error {
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return fmt.Errorf("編譯 dlv 需要 go 指令: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("建立快取目錄失敗: %w", err)
+	}
+
+	tmp := output + ".tmp"
+	cmd := exec.CommandContext(ctx, goBin, "build", "-trimpath", "-o", tmp, "./cmd/dlv")
+	cmd.Dir = srcDir
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0", "GOOS="+platform.GOOS, "GOARCH="+platform.GOARCH)
+	if platform.GOARM != "" {
+		cmd.Env = append(cmd.Env, "GOARM="+platform.GOARM)
+	}
+	if _, err := os.Stat(filepath.Join(srcDir, "vendor", "modules.txt")); err == nil {
+		cmd.Env = append(cmd.Env, "GOFLAGS=-mod=vendor")
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("編譯 dlv 失敗: %w\n%s", err, strings.TrimSpace(string(out)))
+	}
+	if err := os.Rename(tmp, output); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("寫入 dlv 快取失敗: %w", err)
+	}
+	return nil
+}
+
+var (
+	minGoMinorRe = regexp.MustCompile(`MinSupportedVersionOfGoMinor\s*=\s*(\d+)`)
+	maxGoMinorRe = regexp.MustCompile(`MaxSupportedVersionOfGoMinor\s*=\s*(\d+)`)
+)
+
+// warnDlvGoVersion 目標程式的 Go 版本不在 dlv 支援範圍內時輸出警告
+// 有 Delve 原始碼時讀取 pkg/goversion/compat.go 的支援範圍，否則以編譯 dlv 的 Go 版本估計
+func warnDlvGoVersion(dlvPath, srcDir, targetBinary string) {
+	target, err := buildinfo.ReadFile(targetBinary)
+	if err != nil {
+		return
+	}
+	targetMinor := goMinor(target.GoVersion)
+	if targetMinor < 0 {
+		return
+	}
+
+	dlvInfo, err := buildinfo.ReadFile(dlvPath)
+	if err != nil {
+		return
+	}
+	if srcDir == "" && dlvInfo.Main.Path == delveModule && strings.HasPrefix(dlvInfo.Main.Version, "v") {
+		if modCache := goEnv("GOMODCACHE"); modCache != "" {
+			srcDir = filepath.Join(modCache, "github.com", "go-delve", "delve@"+dlvInfo.Main.Version)
+		}
+	}
+
+	if minMinor, maxMinor, ok := supportedGoMinors(srcDir); ok {
+		if targetMinor < minMinor || targetMinor > maxMinor {
+			log.Printf("警告: 目標程式以 %s 編譯，dlv 支援 go1.%d ~ go1.%d，可能無法正常調試",
+				target.GoVersion, minMinor, maxMinor)
+		}
+		return
+	}
+
+	if dlvMinor := goMinor(dlvInfo.GoVersion); dlvMinor >= 0 && targetMinor > dlvMinor {
+		log.Printf("警告: 目標程式以 %s 編譯，比編譯 dlv 的 %s 新，dlv 可能不支援，建議更新 Delve", target.GoVersion, dlvInfo.GoVersion)
+	}
+}
+
+// supportedGoMinors 讀取 Delve 原始碼中宣告的 Go 版本支援範圍
+func supportedGoMinors(srcDir string) (int, int, bool) {
+	if srcDir == "" {
+		return 0, 0, false
+	}
+	data, err := os.ReadFile(filepath.Join(srcDir, "pkg", "goversion", "compat.go"))
+	if err != nil {
+		return 0, 0, false
+	}
+	minMatch, maxMatch := minGoMinorRe.FindSubmatch(data), maxGoMinorRe.FindSubmatch(data)
+	if minMatch == nil || maxMatch == nil {
+		return 0, 0, false
+	}
+	minMinor, _ := strconv.Atoi(string(minMatch[1]))
+	maxMinor, _ := strconv.Atoi(string(maxMatch[1]))
+	return minMinor, maxMinor, true
+}
+
+// goMinor 解析 go1.N[.x] 中的 N，失敗時返回 -1
+func goMinor(version string) int {
+	rest, ok := strings.CutPrefix(version, "go1.")
+	if !ok {
+		return -1
+	}
+	if i := strings.IndexFunc(rest, func(r rune) bool { return r < '0' || r > '9' }); i >= 0 {
+		rest = rest[:i]
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// goEnv 讀取 go env 的值，沒有 go 指令時返回空字串
+func goEnv(key string) string {
+	out, err := exec.Command("go", "env", key).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// versionOf 從模組快取目錄名稱取出版本，例如 delve@v1.23.0 → v1.23.0
+func versionOf(dir string) string {
+	_, version, _ := strings.Cut(filepath.Base(dir), "@")
+	return version
+}
+
+// compareVersions 比較 vX.Y.Z 形式的版本（忽略 pre-release 後綴）
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range pa {
+		if pa[i] != pb[i] {
+			return pa[i] - pb[i]
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) [3]int {
+	var parts [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	for i, field := range strings.SplitN(v, ".", 3) {
+		parts[i], _ = strconv.Atoi(field)
+	}
+	return parts
+}
@@ -0,0 +1,198 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// SourceWatcher 遞迴監控目錄下的原始碼變更，供內建編譯步驟使用
+// 變更在防抖動時間內合併為一次回呼；新建立的子目錄會自動加入監控
+type SourceWatcher struct {
+	root     string
+	include  []string
+	ignore   []string
+	debounce time.Duration
+	callback func(changed []string)
+}
+
+// NewSourceWatcher 創建原始碼監控器
+// include / ignore 為相對於 root 的 glob，支援 ** 匹配多層目錄；不含 / 的 glob 匹配任意目錄下的檔名
+func NewSourceWatcher(root string, include, ignore []string, debounce time.Duration, callback func(changed []string)) *SourceWatcher {
+	return &SourceWatcher{
+		root:     root,
+		include:  include,
+		ignore:   ignore,
+		debounce: debounce,
+		callback: callback,
+	}
+}
+
+// Start 開始監控原始碼變化
+func (sw *SourceWatcher) Start(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("建立原始碼監控器失敗: %w", err)
+	}
+	if err := sw.addTree(watcher, sw.root); err != nil {
+		watcher.Close()
+		return fmt.Errorf("添加原始碼監控目錄失敗: %w", err)
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var (
+			mu            sync.Mutex
+			changed       = make(map[string]bool)
+			debounceTimer *time.Timer
+		)
+		flush := func() {
+			mu.Lock()
+			paths := make([]string, 0, len(changed))
+			for p := range changed {
+				paths = append(paths, p)
+			}
+			changed = make(map[string]bool)
+			mu.Unlock()
+
+			sort.Strings(paths)
+			if len(paths) > 0 && ctx.Err() == nil {
+				sw.callback(paths)
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&fsnotify.Create == fsnotify.Create {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						if err := sw.addTree(watcher, event.Name); err != nil {
+							log.Printf("添加原始碼監控目錄失敗: %v", err)
+						}
+						continue
+					}
+				}
+
+				rel, ok := sw.relevant(event.Name)
+				if !ok {
+					continue
+				}
+				mu.Lock()
+				changed[rel] = true
+				mu.Unlock()
+
+				if debounceTimer != nil {
+					debounceTimer.Stop()
+				}
+				debounceTimer = time.AfterFunc(sw.debounce, flush)
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("原始碼監控錯誤: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// addTree 將目錄及其子目錄加入監控，略過隱藏目錄與被忽略的目錄
+func (sw *SourceWatcher) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != sw.root {
+			rel, err := filepath.Rel(sw.root, p)
+			if err != nil {
+				return nil
+			}
+			if strings.HasPrefix(d.Name(), ".") || MatchGlobs(sw.ignore, filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+		}
+		return watcher.Add(p)
+	})
+}
+
+// relevant 判斷變更的檔案是否需要觸發編譯，返回相對於 root 的路徑
+func (sw *SourceWatcher) relevant(name string) (string, bool) {
+	rel, err := filepath.Rel(sw.root, name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+	if !MatchGlobs(sw.include, rel) || MatchGlobs(sw.ignore, rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// MatchGlobs 判斷以 / 分隔的相對路徑是否符合任一 glob（規則同 NewSourceWatcher）
+func MatchGlobs(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 以 / 分隔的 glob 匹配相對路徑，** 匹配零或多層目錄
+// 不含 / 的 glob 只匹配檔名，例如 *.go 匹配任意目錄下的 Go 檔案
+func matchGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
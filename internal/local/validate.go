@@ -0,0 +1,133 @@
+package local
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// BinaryTarget 執行檔要部署的目標
+type BinaryTarget struct {
+	Platform Platform // 目標容器平台
+	Debugger bool     // 是否以 dlv 啟動，需要調試資訊
+}
+
+// BinaryReport 執行檔的檢查結果
+type BinaryReport struct {
+	GoVersion   string
+	Interpreter string   // 動態連結器，靜態連結時為空
+	Needed      []string // 動態連結的函式庫（DT_NEEDED）
+	Warnings    []string // 可以部署但可能出問題的項目
+}
+
+// ValidateBinary 在上傳前檢查本地執行檔能否在目標容器執行與調試
+// 無法執行的問題（非 Linux ELF、架構不符、啟用 debugger 但缺少調試資訊）返回錯誤，
+// 其餘問題記錄在 Warnings；容器內是否有所需的動態庫由呼叫端依 Interpreter / Needed 檢查
+func ValidateBinary(path string, target BinaryTarget) (*BinaryReport, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		if format := foreignFormat(path); format != "" {
+			return nil, fmt.Errorf("%s 是 %s 執行檔，開發容器需要 Linux 執行檔（GOOS=linux）", path, format)
+		}
+		return nil, fmt.Errorf("%s 不是有效的 ELF 執行檔: %w", path, err)
+	}
+	defer f.Close()
+
+	report := &BinaryReport{}
+	warn := func(format string, args ...any) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	arch, ok := elfMachines[f.Machine]
+	if !ok {
+		arch = f.Machine.String()
+	}
+	if target.Platform.GOARCH != "" && arch != target.Platform.GOARCH {
+		return nil, fmt.Errorf("執行檔架構為 %s，目標容器為 %s，請以 GOARCH=%s 重新編譯", arch, target.Platform.GOARCH, target.Platform.GOARCH)
+	}
+
+	var settings map[string]string
+	if info, err := buildinfo.ReadFile(path); err != nil {
+		warn("無法讀取 Go 建置資訊，略過建置參數檢查: %v", err)
+	} else {
+		report.GoVersion = info.GoVersion
+		settings = make(map[string]string, len(info.Settings))
+		for _, s := range info.Settings {
+			settings[s.Key] = s.Value
+		}
+	}
+	if goos := settings["GOOS"]; goos != "" && goos != "linux" {
+		return nil, fmt.Errorf("執行檔以 GOOS=%s 編譯，開發容器需要 GOOS=linux", goos)
+	}
+	if goarm := settings["GOARM"]; goarm != "" && target.Platform.GOARM != "" && goarm > target.Platform.GOARM {
+		warn("執行檔以 GOARM=%s 編譯，目標容器為 v%s，可能無法執行", goarm, target.Platform.GOARM)
+	}
+
+	report.Interpreter = elfInterpreter(f)
+	if report.Interpreter != "" {
+		report.Needed, _ = f.ImportedLibraries()
+		if target.Platform.Libc == "musl" && !strings.Contains(report.Interpreter, "musl") {
+			warn("執行檔動態連結 glibc（%s），目標容器使用 musl；建議以 CGO_ENABLED=0 編譯，或在 initial_scripts 安裝 libc6-compat", report.Interpreter)
+		}
+	}
+
+	if target.Debugger {
+		if !hasDWARF(f) {
+			return nil, errors.New("執行檔不含調試資訊（可能以 -ldflags=\"-s -w\" 編譯），dlv 無法調試；請移除 -s -w 或關閉 debugger")
+		}
+		if settings != nil && !disablesOptimizations(settings["-gcflags"]) {
+			warn("執行檔未以 -gcflags=\"all=-N -l\" 編譯，最佳化與內聯會讓斷點與變數檢視不準確")
+		}
+	}
+
+	return report, nil
+}
+
+// hasDWARF 檢查是否包含 DWARF 調試資訊（含壓縮的 .zdebug 區段）
+func hasDWARF(f *elf.File) bool {
+	return f.Section(".debug_info") != nil || f.Section(".zdebug_info") != nil
+}
+
+// disablesOptimizations 檢查 -gcflags 是否同時包含 -N 與 -l，可帶套件模式前綴，例如 all=-N -l
+func disablesOptimizations(gcflags string) bool {
+	var noOpt, noInline bool
+	for _, field := range strings.Fields(gcflags) {
+		if pattern, flag, ok := strings.Cut(field, "="); ok && !strings.HasPrefix(pattern, "-") {
+			field = flag
+		}
+		switch field {
+		case "-N":
+			noOpt = true
+		case "-l":
+			noInline = true
+		}
+	}
+	return noOpt && noInline
+}
+
+// foreignFormat 依檔案開頭判斷常見的非 ELF 執行檔格式
+func foreignFormat(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return ""
+	}
+	switch {
+	case bytes.Equal(magic, []byte{0xcf, 0xfa, 0xed, 0xfe}), bytes.Equal(magic, []byte{0xce, 0xfa, 0xed, 0xfe}),
+		bytes.Equal(magic, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		return "macOS（Mach-O）"
+	case bytes.HasPrefix(magic, []byte("MZ")):
+		return "Windows（PE）"
+	}
+	return ""
+}
@@ -1,16 +1,32 @@
 package local
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+const (
+	// watchPollInterval 輪詢檔案狀態的間隔，作為 fsnotify 不可靠時（例如 WSL 掛載的 Windows 目錄）的備援
+	watchPollInterval = time.Second
+	// settleInterval 檔案變更後檢查大小與修改時間的間隔
+	settleInterval = 200 * time.Millisecond
+	// stableDuration 大小與修改時間維持不變多久才視為寫入完成
+	stableDuration = 500 * time.Millisecond
+)
+
 // FileWatcher 監控本地文件變化
+// 檔案大小與修改時間穩定後才比對內容雜湊，內容確實改變時才呼叫 callback，
+// 因此連結器仍在寫入、只有 touch / chmod、或 atomic rename 產生的多個事件都不會造成多餘的部署
 type FileWatcher struct {
 	path     string
 	callback func(string)
@@ -24,57 +40,192 @@ func NewFileWatcher(path string, callback func(string)) *FileWatcher {
 	}
 }
 
+// fileStamp 用於判斷檔案是否仍在變動
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func (s fileStamp) equal(other fileStamp) bool {
+	return s.size == other.size && s.modTime.Equal(other.modTime)
+}
+
+func statFile(path string) (fileStamp, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return fileStamp{}, false
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, true
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 // Start 開始監控文件變化
+// 監控檔案所在的目錄；目錄尚不存在時監控最近的上層目錄，建立後再往下加入監控
+// fsnotify 無法使用時只以輪詢偵測變更
 func (fw *FileWatcher) Start(ctx context.Context) error {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		return fmt.Errorf("建立檔案監控器失敗: %w", err)
+		log.Printf("建立檔案監控器失敗，改為每 %s 輪詢: %v", watchPollInterval, err)
+		watcher = nil
 	}
 
-	// 監控檔案所在的目錄
 	dir := filepath.Dir(fw.path)
-	if err := watcher.Add(dir); err != nil {
+	watched := ""
+	addWatch := func() error {
+		if watcher == nil {
+			return nil
+		}
+		target := dir
+		for {
+			if info, err := os.Stat(target); err == nil && info.IsDir() {
+				break
+			}
+			parent := filepath.Dir(target)
+			if parent == target {
+				return fmt.Errorf("找不到可監控的目錄: %s", dir)
+			}
+			target = parent
+		}
+		if target == watched {
+			return nil
+		}
+		if err := watcher.Add(target); err != nil {
+			return err
+		}
+		if watched != "" && watched != target {
+			watcher.Remove(watched)
+		}
+		if target != dir {
+			log.Printf("監控目錄 %s 尚不存在，暫時監控 %s", dir, target)
+		}
+		watched = target
+		return nil
+	}
+	if err := addWatch(); err != nil {
+		watcher.Close()
 		return fmt.Errorf("添加監控目錄失敗: %w", err)
 	}
 
+	// 啟動時的檔案已部署，以它作為比對基準
+	known, _ := statFile(fw.path)
+	knownHash, _ := hashFile(fw.path)
+
 	go func() {
-		defer watcher.Close()
+		var (
+			events <-chan fsnotify.Event
+			errs   <-chan error
+		)
+		if watcher != nil {
+			defer watcher.Close()
+			events, errs = watcher.Events, watcher.Errors
+		}
 
-		// 防抖動計時器
-		var debounceTimer *time.Timer
-		const debounceDelay = 500 * time.Millisecond
+		pollTicker := time.NewTicker(watchPollInterval)
+		defer pollTicker.Stop()
+
+		// settling 表示檔案有變動，正在等待大小與修改時間穩定
+		var (
+			settling    bool
+			settleTick  <-chan time.Time
+			settleTimer *time.Ticker
+			candidate   fileStamp
+			since       time.Time
+		)
+		startSettle := func() {
+			if settling {
+				return
+			}
+			settling = true
+			candidate, _ = statFile(fw.path)
+			since = time.Now()
+			settleTimer = time.NewTicker(settleInterval)
+			settleTick = settleTimer.C
+		}
+		stopSettle := func() {
+			settling = false
+			if settleTimer != nil {
+				settleTimer.Stop()
+			}
+			settleTick = nil
+		}
+		defer stopSettle()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
 
-			case event, ok := <-watcher.Events:
+			case event, ok := <-events:
 				if !ok {
-					return
+					events = nil
+					continue
 				}
-
-				// 只關注目標檔案的寫入和建立事件
-				// it event.op always be trigger as chmod event with go build under wsl for some reason
-				//if event.Name == fw.path && (event.Op&fsnotify.Write == fsnotify.Write ||
-				//	event.Op&fsnotify.Create == fsnotify.Create) {
+				// 監控的目錄被建立（或被移除）時重新選擇要監控的目錄
+				if event.Name == watched || strings.HasPrefix(dir, event.Name+string(filepath.Separator)) || event.Name == dir {
+					if err := addWatch(); err != nil {
+						log.Printf("添加監控目錄失敗: %v", err)
+					}
+				}
+				// 不區分事件類型：WSL 下 go build 可能只產生 chmod 事件，atomic rename 則只有 create / rename
 				if event.Name == fw.path {
+					startSettle()
+				}
 
-					// 重置防抖動計時器
-					if debounceTimer != nil {
-						debounceTimer.Stop()
-					}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				log.Printf("檔案監控錯誤: %v", err)
 
-					debounceTimer = time.AfterFunc(debounceDelay, func() {
-						fw.callback(event.Name)
-					})
+			case <-pollTicker.C:
+				if !settling {
+					if stamp, ok := statFile(fw.path); ok && !stamp.equal(known) {
+						startSettle()
+					}
 				}
 
-			case err, ok := <-watcher.Errors:
+			case <-settleTick:
+				stamp, ok := statFile(fw.path)
 				if !ok {
-					return
+					// 檔案被刪除（例如先刪除再重建），重新出現時由事件或輪詢再次觸發
+					stopSettle()
+					continue
 				}
-				log.Printf("檔案監控錯誤: %v", err)
+				if !stamp.equal(candidate) {
+					candidate, since = stamp, time.Now()
+					continue
+				}
+				if time.Since(since) < stableDuration {
+					continue
+				}
+				stopSettle()
+
+				hash, err := hashFile(fw.path)
+				if err != nil {
+					log.Printf("讀取檔案失敗: %v", err)
+					continue
+				}
+				known = stamp
+				if bytes.Equal(hash, knownHash) {
+					log.Printf("檔案內容未變更，略過部署: %s", fw.path)
+					continue
+				}
+				knownHash = hash
+				fw.callback(fw.path)
 			}
 		}
 	}()
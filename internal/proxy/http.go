@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// hopHeaders 代理轉發時需移除的逐跳標頭
+var hopHeaders = []string{
+	"Proxy-Connection",
+	"Proxy-Authorization",
+	"Proxy-Authenticate",
+	"Keep-Alive",
+	"Te",
+	"Trailer",
+	"Upgrade",
+}
+
+// handleHTTP 處理 HTTP 代理請求
+// CONNECT 建立隧道；絕對 URI 的一般請求轉發後關閉連線（每個連線只處理一個請求）
+func (s *Server) handleHTTP(client *bufferedConn) error {
+	req, err := http.ReadRequest(client.reader)
+	if err != nil {
+		return nil
+	}
+
+	if req.Method == http.MethodConnect {
+		remote, err := s.dialTarget(req.Host)
+		if err != nil {
+			writeHTTPError(client, http.StatusBadGateway, err)
+			return nil
+		}
+		defer remote.Close()
+
+		if _, err := fmt.Fprint(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+			return nil
+		}
+		s.stats.Pipe(client, remote)
+		return nil
+	}
+
+	if !req.URL.IsAbs() {
+		writeHTTPError(client, http.StatusBadRequest, fmt.Errorf("代理請求必須使用絕對 URI"))
+		return nil
+	}
+	if req.URL.Scheme != "http" {
+		writeHTTPError(client, http.StatusBadRequest, fmt.Errorf("不支援的協定 %s，HTTPS 請使用 CONNECT", req.URL.Scheme))
+		return nil
+	}
+
+	addr := req.URL.Host
+	if req.URL.Port() == "" {
+		addr = net.JoinHostPort(req.URL.Hostname(), "80")
+	}
+	remote, err := s.dialTarget(addr)
+	if err != nil {
+		writeHTTPError(client, http.StatusBadGateway, err)
+		return nil
+	}
+	defer remote.Close()
+
+	for _, header := range hopHeaders {
+		req.Header.Del(header)
+	}
+	req.Close = true
+	if err := req.Write(remote); err != nil {
+		writeHTTPError(client, http.StatusBadGateway, err)
+		return err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(remote), req)
+	if err != nil {
+		writeHTTPError(client, http.StatusBadGateway, err)
+		return err
+	}
+	defer resp.Body.Close()
+	resp.Close = true
+	return resp.Write(client)
+}
+
+// writeHTTPError 回覆代理錯誤
+func writeHTTPError(client net.Conn, status int, err error) {
+	body := err.Error() + "\n"
+	fmt.Fprintf(client, "HTTP/1.1 %d %s\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Length: %d\r\nConnection: close\r\n\r\n%s",
+		status, http.StatusText(status), len(body), body)
+}
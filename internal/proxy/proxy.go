@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+// DialFunc 建立到目標地址（host:port）的連線，host 可以是尚未解析的名稱
+type DialFunc func(ctx context.Context, addr string) (net.Conn, error)
+
+// Server 同一個端口同時提供 SOCKS5 與 HTTP（CONNECT 與絕對 URI 請求）代理
+// 依連線的第一個位元組判斷協定：0x05 為 SOCKS5，其餘視為 HTTP
+type Server struct {
+	listener net.Listener
+	dial     DialFunc
+	ctx      context.Context
+	stop     func() bool
+
+	stats executor.ConnStats
+}
+
+// New 在 listener 上啟動代理，ctx 結束時自動關閉
+func New(ctx context.Context, listener net.Listener, dial DialFunc) *Server {
+	s := &Server{
+		listener: listener,
+		dial:     dial,
+		ctx:      ctx,
+	}
+	s.stop = context.AfterFunc(ctx, func() {
+		listener.Close()
+	})
+	go s.serve()
+	return s
+}
+
+// ListenPort 返回代理實際監聽的端口
+func (s *Server) ListenPort() int {
+	if addr, ok := s.listener.Addr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// Stats 返回連線統計
+func (s *Server) Stats() executor.TunnelStats {
+	return s.stats.Snapshot()
+}
+
+// SetLogConnections 設定是否在工作日誌輸出每個連線的建立與關閉
+func (s *Server) SetLogConnections(enabled bool) {
+	s.stats.SetLogConnections(enabled)
+}
+
+// Close 停止代理
+func (s *Server) Close() error {
+	s.stop()
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			defer s.stats.Opened(conn)()
+			if err := s.handle(conn); err != nil {
+				log.Printf("代理請求失敗: %v", err)
+			}
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+	first, err := reader.Peek(1)
+	if err != nil {
+		return nil
+	}
+
+	client := &bufferedConn{Conn: conn, reader: reader}
+	if first[0] == socksVersion {
+		return s.handleSOCKS(client)
+	}
+	return s.handleHTTP(client)
+}
+
+// bufferedConn 讀取時先消耗協定偵測時已緩衝的資料
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// dialTarget 連接目標，失敗時記錄原因（已寫入工作日誌，返回的錯誤只回覆給客戶端）
+func (s *Server) dialTarget(addr string) (net.Conn, error) {
+	remote, err := s.dial(s.ctx, addr)
+	if err != nil {
+		reason := s.stats.DialFailed(fmt.Sprintf("代理 %s", addr), err)
+		return nil, fmt.Errorf("連接 %s 失敗: %s", addr, reason)
+	}
+	return remote, nil
+}
@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+)
+
+// SOCKS5 協定常數（RFC 1928）
+const (
+	socksVersion = 0x05
+
+	socksMethodNoAuth       = 0x00
+	socksMethodNoAcceptable = 0xff
+
+	socksCmdConnect = 0x01
+
+	socksAddrIPv4   = 0x01
+	socksAddrDomain = 0x03
+	socksAddrIPv6   = 0x04
+
+	socksReplySucceeded          = 0x00
+	socksReplyGeneralFailure     = 0x01
+	socksReplyHostUnreachable    = 0x04
+	socksReplyCommandUnsupported = 0x07
+	socksReplyAddrUnsupported    = 0x08
+)
+
+// handleSOCKS 處理 SOCKS5 連線，僅支援無認證的 CONNECT
+// 使用 socks5h:// 時名稱由代理端解析，compose 服務名稱才能被正確解析
+func (s *Server) handleSOCKS(client net.Conn) error {
+	// 協商認證方式
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(client, header); err != nil {
+		return nil
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(client, methods); err != nil {
+		return nil
+	}
+	noAuth := false
+	for _, method := range methods {
+		if method == socksMethodNoAuth {
+			noAuth = true
+		}
+	}
+	if !noAuth {
+		client.Write([]byte{socksVersion, socksMethodNoAcceptable})
+		return fmt.Errorf("SOCKS5 客戶端不支援無認證模式")
+	}
+	if _, err := client.Write([]byte{socksVersion, socksMethodNoAuth}); err != nil {
+		return nil
+	}
+
+	// 讀取請求：VER CMD RSV ATYP
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(client, request); err != nil {
+		return nil
+	}
+	if request[1] != socksCmdConnect {
+		writeSOCKSReply(client, socksReplyCommandUnsupported)
+		return fmt.Errorf("不支援的 SOCKS5 命令: %d", request[1])
+	}
+
+	host, err := readSOCKSAddr(client, request[3])
+	if err != nil {
+		writeSOCKSReply(client, socksReplyAddrUnsupported)
+		return err
+	}
+	portBytes := make([]byte, 2)
+	if _, err := io.ReadFull(client, portBytes); err != nil {
+		return nil
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(portBytes))))
+
+	remote, err := s.dialTarget(addr)
+	if err != nil {
+		writeSOCKSReply(client, socksReplyHostUnreachable)
+		return nil
+	}
+	defer remote.Close()
+
+	if err := writeSOCKSReply(client, socksReplySucceeded); err != nil {
+		return nil
+	}
+	s.stats.Pipe(client, remote)
+	return nil
+}
+
+// readSOCKSAddr 依地址類型讀取目標主機
+func readSOCKSAddr(r io.Reader, addrType byte) (string, error) {
+	switch addrType {
+	case socksAddrIPv4:
+		ip := make([]byte, net.IPv4len)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		return net.IP(ip).String(), nil
+	case socksAddrIPv6:
+		ip := make([]byte, net.IPv6len)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		return net.IP(ip).String(), nil
+	case socksAddrDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(r, length); err != nil {
+			return "", err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(r, domain); err != nil {
+			return "", err
+		}
+		return string(domain), nil
+	default:
+		return "", fmt.Errorf("不支援的 SOCKS5 地址類型: %d", addrType)
+	}
+}
+
+// writeSOCKSReply 回覆請求結果，綁定地址固定填 0.0.0.0:0
+func writeSOCKSReply(w io.Writer, reply byte) error {
+	_, err := w.Write([]byte{socksVersion, reply, 0x00, socksAddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package shell
+
+import "context"
+
+// watchResize 此平台不支援 SIGWINCH，不追蹤終端尺寸變更
+func watchResize(ctx context.Context, onResize func()) func() {
+	return func() {}
+}
@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/cancelreader"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+)
+
+const defaultTerm = "xterm-256color"
+
+// Command 進入開發容器的互動式 shell
+// 實現了 Bubble Tea 的 ExecCommand 介面，可直接交給 TUI 暫停介面後執行
+type Command struct {
+	ctx           context.Context
+	executor      executor.Executor
+	manager       *docker.Manager
+	containerName string
+
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// NewCommand 建立互動式 shell 命令，預設使用當前進程的標準輸入輸出
+func NewCommand(ctx context.Context, exec executor.Executor, manager *docker.Manager, containerName string) *Command {
+	return &Command{
+		ctx:           ctx,
+		executor:      exec,
+		manager:       manager,
+		containerName: containerName,
+		stdin:         os.Stdin,
+		stdout:        os.Stdout,
+		stderr:        os.Stderr,
+	}
+}
+
+func (c *Command) SetStdin(r io.Reader)  { c.stdin = r }
+func (c *Command) SetStdout(w io.Writer) { c.stdout = w }
+func (c *Command) SetStderr(w io.Writer) { c.stderr = w }
+
+// Run 開啟 PTY session 並轉接終端輸入輸出，shell 結束後恢復終端狀態
+// shell 本身的非零退出碼不視為錯誤
+func (c *Command) Run() error {
+	ctx, cancel := context.WithCancel(c.ctx)
+	defer cancel()
+
+	shell, err := c.manager.DetectShell(ctx, c.containerName)
+	if err != nil {
+		return err
+	}
+
+	termName := os.Getenv("TERM")
+	if termName == "" {
+		termName = defaultTerm
+	}
+
+	rows, cols := 24, 80
+	outFile, outIsTerm := terminalFile(c.stdout)
+	if outIsTerm {
+		if w, h, err := term.GetSize(outFile.Fd()); err == nil {
+			rows, cols = h, w
+		}
+	}
+
+	session, err := c.executor.CreateSession(ctx)
+	if err != nil {
+		return fmt.Errorf("建立 session 失敗: %w", err)
+	}
+	defer session.Close()
+
+	if err := session.RequestPTY(termName, rows, cols); err != nil {
+		return err
+	}
+	if err := session.Start(ctx, c.manager.ShellCommand(c.containerName, shell, termName)); err != nil {
+		return fmt.Errorf("啟動 shell 失敗: %w", err)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+
+	// 本地終端切換為 raw 模式，按鍵原樣轉發給遠端 PTY
+	if inFile, ok := terminalFile(c.stdin); ok {
+		state, err := term.MakeRaw(inFile.Fd())
+		if err != nil {
+			return fmt.Errorf("切換終端 raw 模式失敗: %w", err)
+		}
+		defer term.Restore(inFile.Fd(), state)
+	}
+
+	if outIsTerm {
+		stopResize := watchResize(ctx, func() {
+			if w, h, err := term.GetSize(outFile.Fd()); err == nil {
+				session.WindowChange(h, w)
+			}
+		})
+		defer stopResize()
+	}
+
+	// 標準輸入需可取消，否則 shell 結束後殘留的讀取會吃掉 TUI 的下一個按鍵
+	input, err := cancelreader.NewReader(c.stdin)
+	if err != nil {
+		return fmt.Errorf("建立輸入轉發失敗: %w", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(stdin, input)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(c.stdout, stdout)
+	}()
+
+	waitErr := session.Wait()
+	input.Cancel()
+	session.Close()
+	wg.Wait()
+	input.Close()
+
+	var exitErr *executor.ExitError
+	if waitErr != nil && !errors.As(waitErr, &exitErr) {
+		return fmt.Errorf("shell 異常結束: %w", waitErr)
+	}
+	return nil
+}
+
+// terminalFile 判斷讀寫端是否為終端檔案
+func terminalFile(v any) (*os.File, bool) {
+	f, ok := v.(*os.File)
+	if !ok || !term.IsTerminal(f.Fd()) {
+		return nil, false
+	}
+	return f, true
+}
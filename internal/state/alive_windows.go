@@ -0,0 +1,28 @@
+//go:build windows
+
+package state
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive GetExitCodeProcess 對執行中程序返回的退出碼（STILL_ACTIVE）
+const stillActive = 259
+
+// processAlive Windows 不支援 signal 0，改為開啟程序並查詢退出碼
+func processAlive(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		// 程序存在但屬於其他使用者或權限較高
+		return errors.Is(err, windows.ERROR_ACCESS_DENIED)
+	}
+	defer windows.CloseHandle(handle)
+
+	var code uint32
+	if err := windows.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
@@ -0,0 +1,174 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// appDir 狀態檔所在的子目錄名稱（位於使用者快取目錄下）
+const appDir = "docker-dev-swap"
+
+// State 執行中 session 的狀態，寫入 JSON 檔案供 IDE 設定與腳本查詢實際使用的端口
+type State struct {
+	PID          int       `json:"pid"`
+	Component    string    `json:"component"`
+	Host         string    `json:"host"`
+	Project      string    `json:"project"`
+	Mode         string    `json:"mode"`
+	DevContainer string    `json:"dev_container"`
+	StartedAt    time.Time `json:"started_at"`
+	UpdatedAt    time.Time `json:"updated_at"`        // 連線統計最後更新時間
+	Version      *Version  `json:"version,omitempty"` // 目前部署的執行檔版本
+	Forwards     []Forward `json:"forwards"`
+}
+
+// Version 部署過的執行檔版本，VCS 資訊讀取自執行檔內嵌的 debug/buildinfo
+type Version struct {
+	Hash       string    `json:"hash"` // 執行檔內容的 SHA-256
+	GoVersion  string    `json:"go_version,omitempty"`
+	Revision   string    `json:"revision,omitempty"` // vcs.revision
+	Modified   bool      `json:"modified,omitempty"` // vcs.modified：編譯時工作目錄有未提交的變更
+	BuildTime  time.Time `json:"build_time"`
+	DeployedAt time.Time `json:"deployed_at"`
+}
+
+// ShortHash 返回顯示用的短 hash
+func (v *Version) ShortHash() string {
+	if len(v.Hash) > 12 {
+		return v.Hash[:12]
+	}
+	return v.Hash
+}
+
+// ShortRevision 返回顯示用的短 revision，未提交的變更以 + 標示
+func (v *Version) ShortRevision() string {
+	rev := v.Revision
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if rev != "" && v.Modified {
+		rev += "+"
+	}
+	return rev
+}
+
+// Forward 已建立的端口轉發
+type Forward struct {
+	Name      string `json:"name"`
+	LocalPort int    `json:"local_port"`        // 本地實際監聽（或連接）的端口
+	Target    string `json:"target"`            // 轉發目標
+	Reverse   bool   `json:"reverse,omitempty"` // 反向轉發（遠端 → 本地）
+	Direct    bool   `json:"direct,omitempty"`  // 不經過 tunnel，直接連接
+
+	// 連線統計
+	Active      int64      `json:"active"`
+	Total       int64      `json:"total"`
+	BytesIn     int64      `json:"bytes_in"`  // 目標 → 本地
+	BytesOut    int64      `json:"bytes_out"` // 本地 → 目標
+	DialErrors  int64      `json:"dial_errors"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Dir 返回狀態檔目錄
+func Dir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("無法取得使用者快取目錄: %w", err)
+	}
+	return filepath.Join(cacheDir, appDir), nil
+}
+
+// Path 返回指定 component 與 host 的狀態檔路徑：<快取目錄>/docker-dev-swap/<component>@<host>.json
+func Path(component, host string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("%s@%s.json", sanitize(component), sanitize(host))), nil
+}
+
+// Write 寫入狀態檔（先寫入暫存檔再改名，避免讀取端讀到不完整的內容）
+func Write(path string, st *State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("建立狀態目錄失敗: %w", err)
+	}
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("編碼狀態失敗: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("寫入狀態檔失敗: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("寫入狀態檔失敗: %w", err)
+	}
+	return nil
+}
+
+// Load 讀取狀態檔
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取狀態檔失敗: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("解析狀態檔失敗: %w", err)
+	}
+	return &st, nil
+}
+
+// Remove 刪除狀態檔，檔案不存在時不視為錯誤
+func Remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("刪除狀態檔失敗: %w", err)
+	}
+	return nil
+}
+
+// List 返回所有狀態檔，無法解析的檔案會被略過
+func List() ([]*State, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var states []*State
+	for _, path := range paths {
+		st, err := Load(path)
+		if err != nil {
+			continue
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
+
+// Alive 判斷寫入狀態檔的程序是否仍在執行，用來辨識異常退出後殘留的狀態檔
+func (s *State) Alive() bool {
+	return processAlive(s.PID)
+}
+
+// sanitize 將名稱中不適合作為檔名的字元替換為 '_'
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|', ' ':
+			return '_'
+		}
+		return r
+	}, name)
+}
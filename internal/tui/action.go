@@ -6,6 +6,16 @@ type ActionType string
 const (
 	// ActionToggleDebugger toggles the debugger mode on the dev container.
 	ActionToggleDebugger ActionType = "toggle_debugger"
+	// ActionToggleWaitForDebugger toggles whether the target stays paused until a debugger attaches.
+	ActionToggleWaitForDebugger ActionType = "toggle_wait_for_debugger"
+	// ActionResumeDebugger continues a target that is paused awaiting attach.
+	ActionResumeDebugger ActionType = "resume_debugger"
+	// ActionRebuild runs the configured build step immediately.
+	ActionRebuild ActionType = "rebuild"
+	// ActionRollback redeploys a previously deployed binary from the version history.
+	ActionRollback ActionType = "rollback"
+	// ActionOpenShell opens an interactive shell inside the dev container.
+	ActionOpenShell ActionType = "open_shell"
 	// ActionQuit requests the entire program to stop.
 	ActionQuit ActionType = "quit"
 )
@@ -14,4 +24,5 @@ const (
 type Action struct {
 	Type    ActionType
 	Enabled bool
+	Version string // hash of the version to redeploy, for ActionRollback
 }
@@ -5,16 +5,68 @@ import (
 	"errors"
 	"io"
 	"sync"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/delve"
 )
 
+// InteractiveCommand is a command that takes over the terminal while it runs, such as a container shell.
+type InteractiveCommand = tea.ExecCommand
+
 // Options controls the behavior of the Bubble Tea manager.
 type Options struct {
 	InitialDebuggerEnabled bool
+	InitialWaitForDebugger bool
+	BuildEnabled           bool // show the build status and the rebuild key
+	HistoryEnabled         bool // show the version history key
 	MaxLines               int
 }
 
+// BuildPhase describes where the built-in build step is.
+type BuildPhase string
+
+const (
+	BuildRunning   BuildPhase = "running"
+	BuildSucceeded BuildPhase = "succeeded"
+	BuildFailed    BuildPhase = "failed"
+)
+
+// BuildStatus describes the latest run of the built-in build step.
+type BuildStatus struct {
+	Phase    BuildPhase
+	Output   []string // compiler output, shown when the build fails
+	Duration time.Duration
+}
+
+// VersionInfo describes a previously deployed binary kept for rollback.
+type VersionInfo struct {
+	Hash       string
+	ShortHash  string
+	Revision   string // short VCS revision, suffixed with + when built from a modified tree
+	GoVersion  string
+	BuildTime  time.Time
+	DeployedAt time.Time
+	Current    bool // the version currently running in the dev container
+}
+
+// ForwardStatus describes a port forward shown in the status area.
+type ForwardStatus struct {
+	Name        string
+	LocalPort   int
+	Target      string
+	Active      int64
+	Total       int64
+	BytesIn     int64 // bytes received from the target
+	BytesOut    int64 // bytes sent to the target
+	DialErrors  int64
+	LastError   string
+	LastErrorAt time.Time
+	Direct      bool // the target is reachable without a tunnel
+	Reverse     bool // connections flow from Target back to LocalPort
+}
+
 // Manager wires the application logs and shortcut actions into a Bubble Tea program.
 type Manager struct {
 	opts Options
@@ -81,11 +133,40 @@ func (m *Manager) UpdateDebuggerState(enabled bool) {
 	m.send(debuggerStateMsg{enabled: enabled})
 }
 
+// UpdateDebuggerStatus reports whether Delve is starting, ready to accept an IDE, or has failed.
+func (m *Manager) UpdateDebuggerStatus(status delve.Status) {
+	m.send(debuggerStatusMsg(status))
+}
+
+// UpdateBuildStatus reports the progress and compiler errors of the built-in build step.
+func (m *Manager) UpdateBuildStatus(status BuildStatus) {
+	status.Output = append([]string(nil), status.Output...)
+	m.send(buildStatusMsg(status))
+}
+
+// UpdateForwards refreshes the list of port forwards and their connection counts.
+func (m *Manager) UpdateForwards(forwards []ForwardStatus) {
+	m.send(forwardsMsg(append([]ForwardStatus(nil), forwards...)))
+}
+
+// UpdateVersions refreshes the deployed version history, newest first.
+func (m *Manager) UpdateVersions(versions []VersionInfo) {
+	m.send(versionsMsg(append([]VersionInfo(nil), versions...)))
+}
+
+// RunInteractive suspends the UI, runs cmd on the real terminal and restores the UI once it exits.
+func (m *Manager) RunInteractive(cmd InteractiveCommand) {
+	m.send(interactiveMsg{cmd: cmd})
+}
+
 // Start launches the Bubble Tea program and blocks until it terminates or the context is cancelled.
 func (m *Manager) Start(ctx context.Context) error {
 	mdl := newModel(modelOptions{
 		maxLines:               m.opts.MaxLines,
 		initialDebuggerEnabled: m.opts.InitialDebuggerEnabled,
+		initialWaitForDebugger: m.opts.InitialWaitForDebugger,
+		buildEnabled:           m.opts.BuildEnabled,
+		historyEnabled:         m.opts.HistoryEnabled,
 		actionChan:             m.actionChan,
 	})
 
@@ -3,17 +3,27 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/delve"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
 )
 
 type modelOptions struct {
 	maxLines               int
 	initialDebuggerEnabled bool
+	initialWaitForDebugger bool
+	buildEnabled           bool
+	historyEnabled         bool
 	actionChan             chan<- Action
 }
 
+// maxBuildErrorLines caps how much compiler output is shown above the key row.
+const maxBuildErrorLines = 6
+
 type model struct {
 	width  int
 	height int
@@ -23,7 +33,16 @@ type model struct {
 	maxLines       int
 
 	debuggerEnabled bool
+	waitForDebugger bool
+	debuggerStatus  delve.Status
 	statusMessage   string
+	forwards        []ForwardStatus
+	buildEnabled    bool
+	buildStatus     BuildStatus
+	historyEnabled  bool
+	versions        []VersionInfo
+	showVersions    bool
+	versionCursor   int
 
 	actionChan chan<- Action
 }
@@ -36,6 +55,22 @@ type debuggerStateMsg struct {
 	enabled bool
 }
 
+type debuggerStatusMsg delve.Status
+
+type forwardsMsg []ForwardStatus
+
+type buildStatusMsg BuildStatus
+
+type versionsMsg []VersionInfo
+
+type interactiveMsg struct {
+	cmd InteractiveCommand
+}
+
+type interactiveDoneMsg struct {
+	err error
+}
+
 var (
 	titleStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("212"))
 	panelStyle = lipgloss.NewStyle().BorderStyle(lipgloss.NormalBorder()).BorderForeground(lipgloss.Color("240")).Padding(0, 1)
@@ -46,6 +81,9 @@ func newModel(opts modelOptions) model {
 	return model{
 		maxLines:        opts.maxLines,
 		debuggerEnabled: opts.initialDebuggerEnabled,
+		waitForDebugger: opts.initialWaitForDebugger,
+		buildEnabled:    opts.buildEnabled,
+		historyEnabled:  opts.historyEnabled,
 		actionChan:      opts.actionChan,
 	}
 }
@@ -57,6 +95,9 @@ func (m model) Init() tea.Cmd {
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch v := msg.(type) {
 	case tea.KeyMsg:
+		if m.showVersions && m.updateVersionList(v) {
+			return m, nil
+		}
 		switch v.String() {
 		case "ctrl+c", "q":
 			m.sendAction(Action{Type: ActionQuit})
@@ -69,6 +110,53 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			m.statusMessage = fmt.Sprintf("Debugger 已切換為%s，正在套用...", state)
 			m.sendAction(Action{Type: ActionToggleDebugger, Enabled: m.debuggerEnabled})
+		case "w":
+			m.waitForDebugger = !m.waitForDebugger
+			if m.waitForDebugger {
+				m.statusMessage = "下次部署後目標程式將暫停，等待 debugger 連接"
+			} else {
+				m.statusMessage = "下次部署後目標程式將直接執行"
+			}
+			m.sendAction(Action{Type: ActionToggleWaitForDebugger, Enabled: m.waitForDebugger})
+		case "r":
+			if m.debuggerStatus.Phase != delve.PhasePaused {
+				m.statusMessage = "目標程式未處於暫停等待狀態"
+				break
+			}
+			m.statusMessage = "正在繼續執行目標程式..."
+			m.sendAction(Action{Type: ActionResumeDebugger})
+		case "b":
+			if !m.buildEnabled {
+				m.statusMessage = "未設定 build，請自行編譯 local_binary"
+				break
+			}
+			m.statusMessage = "正在重新編譯..."
+			m.sendAction(Action{Type: ActionRebuild})
+		case "s":
+			m.statusMessage = "正在開啟容器 shell..."
+			m.sendAction(Action{Type: ActionOpenShell})
+		case "v":
+			if !m.historyEnabled {
+				m.statusMessage = "未啟用版本紀錄（history 為 0）"
+				break
+			}
+			if len(m.versions) == 0 {
+				m.statusMessage = "尚無部署紀錄"
+				break
+			}
+			m.showVersions = true
+			m.versionCursor = 0
+		}
+	case interactiveMsg:
+		m.statusMessage = "Shell 執行中"
+		return m, tea.Exec(v.cmd, func(err error) tea.Msg {
+			return interactiveDoneMsg{err: err}
+		})
+	case interactiveDoneMsg:
+		if v.err != nil {
+			m.statusMessage = fmt.Sprintf("Shell 失敗: %v", v.err)
+		} else {
+			m.statusMessage = "Shell 已結束"
 		}
 	case tea.WindowSizeMsg:
 		m.width = v.Width
@@ -77,6 +165,41 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.workLines = appendLine(m.workLines, string(v), m.maxLines)
 	case containerLogMsg:
 		m.containerLines = appendLine(m.containerLines, string(v), m.maxLines)
+	case forwardsMsg:
+		m.forwards = v
+	case versionsMsg:
+		m.versions = v
+		if m.versionCursor >= len(m.versions) {
+			m.versionCursor = len(m.versions) - 1
+		}
+		if len(m.versions) == 0 {
+			m.showVersions = false
+			m.versionCursor = 0
+		}
+	case buildStatusMsg:
+		m.buildStatus = BuildStatus(v)
+		switch v.Phase {
+		case BuildRunning:
+			m.statusMessage = "編譯中..."
+		case BuildSucceeded:
+			m.statusMessage = fmt.Sprintf("編譯成功（%s），正在部署", v.Duration.Round(100*time.Millisecond))
+		case BuildFailed:
+			m.statusMessage = "編譯失敗，修正後會自動重新編譯"
+		}
+	case debuggerStatusMsg:
+		m.debuggerStatus = delve.Status(v)
+		switch v.Phase {
+		case delve.PhaseReady:
+			m.statusMessage = fmt.Sprintf("Debugger 已就緒: %s", v.Addr)
+		case delve.PhasePaused:
+			if v.Reason != "" {
+				m.statusMessage = fmt.Sprintf("%s（按 R 重試）", v.Reason)
+				break
+			}
+			m.statusMessage = fmt.Sprintf("目標程式已暫停，等待 debugger 連接 %s（按 R 繼續執行）", v.Addr)
+		case delve.PhaseFailed:
+			m.statusMessage = fmt.Sprintf("Debugger 啟動失敗: %s", v.Reason)
+		}
 	case debuggerStateMsg:
 		m.debuggerEnabled = v.enabled
 		state := "關閉"
@@ -95,6 +218,19 @@ func (m model) View() string {
 	}
 
 	keyRowHeight := 1
+	if len(m.forwards) > 0 {
+		keyRowHeight++
+	}
+	if m.forwardError() != "" {
+		keyRowHeight++
+	}
+	buildErrors := m.buildErrorLines()
+	if len(buildErrors) > 0 {
+		keyRowHeight += len(buildErrors) + 1
+	}
+	if m.showVersions {
+		keyRowHeight += len(m.versions) + 1
+	}
 	available := m.height - keyRowHeight
 	if available < 6 {
 		available = m.height
@@ -112,7 +248,142 @@ func (m model) View() string {
 
 	work := m.renderPanel("工作日誌", m.workLines, topHeight)
 	container := m.renderPanel("容器輸出", m.containerLines, bottomHeight)
-	return lipgloss.JoinVertical(lipgloss.Left, work, container, m.renderKeyRow())
+	rows := []string{work, container}
+	if len(m.forwards) > 0 {
+		rows = append(rows, m.renderForwardRow())
+		if errLine := m.forwardError(); errLine != "" {
+			rows = append(rows, m.renderForwardError(errLine))
+		}
+	}
+	if len(buildErrors) > 0 {
+		rows = append(rows, m.renderBuildErrors(buildErrors))
+	}
+	if m.showVersions {
+		rows = append(rows, m.renderVersions())
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, append(rows, m.renderKeyRow())...)
+}
+
+// buildErrorLines returns the leading compiler output of a failed build.
+func (m model) buildErrorLines() []string {
+	if m.buildStatus.Phase != BuildFailed {
+		return nil
+	}
+	lines := m.buildStatus.Output
+	if len(lines) == 0 {
+		lines = []string{"（編譯命令沒有輸出）"}
+	}
+	if len(lines) > maxBuildErrorLines {
+		lines = append(append([]string(nil), lines[:maxBuildErrorLines-1]...), fmt.Sprintf("...（另有 %d 行，完整輸出見工作日誌）", len(lines)-maxBuildErrorLines+1))
+	}
+	return lines
+}
+
+func (m model) renderBuildErrors(lines []string) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Width(m.width).MaxWidth(m.width).Padding(0, 1).MaxHeight(1)
+	rows := []string{titleStyle.Render("編譯錯誤")}
+	for _, line := range lines {
+		rows = append(rows, style.Render(line))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+// updateVersionList handles keys while the version list is open and reports whether the key was consumed.
+func (m *model) updateVersionList(key tea.KeyMsg) bool {
+	switch key.String() {
+	case "up", "k":
+		if m.versionCursor > 0 {
+			m.versionCursor--
+		}
+	case "down", "j":
+		if m.versionCursor < len(m.versions)-1 {
+			m.versionCursor++
+		}
+	case "esc", "v":
+		m.showVersions = false
+	case "enter":
+		selected := m.versions[m.versionCursor]
+		m.showVersions = false
+		if selected.Current {
+			m.statusMessage = fmt.Sprintf("%s 已是目前的版本", selected.ShortHash)
+			break
+		}
+		m.statusMessage = fmt.Sprintf("正在部署 %s...", selected.ShortHash)
+		m.sendAction(Action{Type: ActionRollback, Version: selected.Hash})
+	default:
+		return false
+	}
+	return true
+}
+
+// renderVersions lists the deployed versions with the cursor on the selected one.
+func (m model) renderVersions() string {
+	style := lipgloss.NewStyle().Width(m.width).MaxWidth(m.width).Padding(0, 1).MaxHeight(1)
+	rows := []string{titleStyle.Render("部署紀錄（↑/↓ 選擇，Enter 部署，Esc 關閉）")}
+	for i, v := range m.versions {
+		cursor := "  "
+		if i == m.versionCursor {
+			cursor = "> "
+		}
+		marker := " "
+		if v.Current {
+			marker = "*"
+		}
+		revision := v.Revision
+		if revision == "" {
+			revision = "-"
+		}
+		line := fmt.Sprintf("%s%s %s  %-13s  編譯於 %s  部署於 %s", cursor, marker, v.ShortHash, revision,
+			v.BuildTime.Format("01-02 15:04:05"), v.DeployedAt.Format("01-02 15:04:05"))
+		if v.GoVersion != "" {
+			line += "  " + v.GoVersion
+		}
+		rowStyle := style
+		if i == m.versionCursor {
+			rowStyle = rowStyle.Bold(true).Foreground(lipgloss.Color("212"))
+		}
+		rows = append(rows, rowStyle.Render(line))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func (m model) renderForwardRow() string {
+	parts := make([]string, 0, len(m.forwards))
+	for _, f := range m.forwards {
+		if f.Direct {
+			parts = append(parts, fmt.Sprintf("%s :%d (直連)", f.Name, f.LocalPort))
+			continue
+		}
+		stats := fmt.Sprintf("%d 連線 / 共 %d  ↑%s ↓%s", f.Active, f.Total, state.FormatBytes(f.BytesOut), state.FormatBytes(f.BytesIn))
+		if f.DialErrors > 0 {
+			stats += fmt.Sprintf("  %d 錯誤", f.DialErrors)
+		}
+		if f.Reverse {
+			parts = append(parts, fmt.Sprintf("%s %s→:%d (%s)", f.Name, f.Target, f.LocalPort, stats))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s :%d→%s (%s)", f.Name, f.LocalPort, f.Target, stats))
+	}
+	return lipgloss.NewStyle().Width(m.width).MaxWidth(m.width).Padding(0, 1).MaxHeight(1).Render("轉發: " + strings.Join(parts, "  •  "))
+}
+
+// forwardError returns the most recent dial failure across all forwards, if any.
+func (m model) forwardError() string {
+	var latest *ForwardStatus
+	for i := range m.forwards {
+		f := &m.forwards[i]
+		if f.LastError != "" && (latest == nil || f.LastErrorAt.After(latest.LastErrorAt)) {
+			latest = f
+		}
+	}
+	if latest == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s: %s", latest.LastErrorAt.Format("15:04:05"), latest.Name, latest.LastError)
+}
+
+func (m model) renderForwardError(line string) string {
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("160")).Width(m.width).MaxWidth(m.width).Padding(0, 1).MaxHeight(1).Render("最近錯誤: " + line)
 }
 
 func (m model) renderPanel(title string, lines []string, height int) string {
@@ -137,7 +408,24 @@ func (m model) renderKeyRow() string {
 	stateView := lipgloss.NewStyle().Bold(true).Foreground(statusColor).Render(state)
 	//lipgloss.NewStyle().Foreground(lipgloss.Color("230")).Background(lipgloss.Color("60"))
 	info := fmt.Sprintf("[D] Debugger %s", stateView)
-	otherInfo := "   [Ctrl+C] 退出"
+	if m.debuggerEnabled {
+		info += m.renderDebuggerPhase()
+	}
+	waitState := "OFF"
+	if m.waitForDebugger {
+		waitState = "ON"
+	}
+	otherInfo := fmt.Sprintf("   [W] 等待連接 %s", waitState)
+	if m.debuggerStatus.Phase == delve.PhasePaused {
+		otherInfo += "   [R] 繼續執行"
+	}
+	if m.buildEnabled {
+		otherInfo += "   [B] 重新編譯" + m.renderBuildPhase()
+	}
+	if m.historyEnabled {
+		otherInfo += "   [V] 版本"
+	}
+	otherInfo += "   [S] Shell   [Ctrl+C] 退出"
 
 	if m.statusMessage != "" {
 		otherInfo = fmt.Sprintf("%s  •  %s", otherInfo, m.statusMessage)
@@ -146,6 +434,40 @@ func (m model) renderKeyRow() string {
 	return keyStyle.Width(m.width).Padding(0, 1).Render(info)
 }
 
+// renderDebuggerPhase shows whether Delve is accepting connections yet.
+func (m model) renderDebuggerPhase() string {
+	var (
+		label string
+		color lipgloss.Color
+	)
+	switch m.debuggerStatus.Phase {
+	case delve.PhaseStarting:
+		label, color = "啟動中", lipgloss.Color("214")
+	case delve.PhaseReady:
+		label, color = "就緒", lipgloss.Color("40")
+	case delve.PhasePaused:
+		label, color = "已暫停，等待連接", lipgloss.Color("214")
+	case delve.PhaseFailed:
+		label, color = "失敗", lipgloss.Color("160")
+	default:
+		return ""
+	}
+	return keyStyle.Render(" ") + keyStyle.Foreground(color).Render("("+label+")")
+}
+
+// renderBuildPhase shows the outcome of the latest build next to the rebuild key.
+func (m model) renderBuildPhase() string {
+	switch m.buildStatus.Phase {
+	case BuildRunning:
+		return " (編譯中)"
+	case BuildSucceeded:
+		return " (成功)"
+	case BuildFailed:
+		return " (失敗)"
+	}
+	return ""
+}
+
 func (m model) sendAction(a Action) {
 	if m.actionChan == nil {
 		return
@@ -8,14 +8,18 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
 
 	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/delve"
 	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
 	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
 	"github.com/laysdragon/go-docker-dev-swap/internal/local"
+	"github.com/laysdragon/go-docker-dev-swap/internal/shell"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
 	"github.com/laysdragon/go-docker-dev-swap/internal/tui"
 )
 
@@ -25,23 +29,28 @@ var (
 )
 
 func main() {
+	flag.Usage = usage
 	flag.Parse()
 
-	// 載入配置
-	cfg, err := config.Load(*configFile)
-	if err != nil {
-		log.Fatalf("載入配置失敗: %v", err)
+	// 子命令
+	switch flag.Arg(0) {
+	case "":
+	case "shell":
+		runShellCommand()
+		return
+	case "status":
+		runStatusCommand()
+		return
+	case "ide":
+		runIDECommand(flag.Args()[1:])
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "未知的子命令: %s\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	// 互動式選擇配置（支援多組配置）
-	runtimeCfg, err := cfg.InteractiveSelect()
-	if err != nil {
-		log.Fatalf("選擇配置失敗: %v", err)
-	}
-
-	if runtimeCfg.Component.TargetService == "" {
-		log.Fatal("必須指定目標服務名稱")
-	}
+	runtimeCfg := loadRuntimeConfig()
 
 	log.Printf("啟動 docker-dev-swap")
 	log.Printf("執行模式: %s", runtimeCfg.Mode)
@@ -63,7 +72,18 @@ func main() {
 	}
 
 	// 建立 Executor
-	exec, err := executor.NewExecutor(runtimeCfg)
+	var (
+		exec     executor.Executor
+		recorder *executor.RecordingExecutor
+		err      error
+	)
+	if *dryRun {
+		log.Println("乾跑模式：不會對主機做任何變更")
+		recorder, err = newDryRunExecutor(runtimeCfg)
+		exec = recorder
+	} else {
+		exec, err = executor.NewExecutor(runtimeCfg)
+	}
 	if err != nil {
 		log.Fatalf("建立 Executor 失敗: %v", err)
 	}
@@ -86,8 +106,22 @@ func main() {
 		cancel()
 	}()
 
+	ideTargets, err := parseEditors(*ideEditors)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *ideEditors == "" {
+		ideTargets = nil
+	}
+
 	runOpts := runOptions{
-		Cancel: cancel,
+		Cancel:     cancel,
+		DryRun:     *dryRun,
+		IDEEditors: ideTargets,
+	}
+	if *dryRun {
+		// 乾跑無法實際清理殘留容器，直接沿用預設回答
+		runOpts.AutoConfirmPrompts = true
 	}
 
 	var (
@@ -95,9 +129,12 @@ func main() {
 		uiErrCh   chan error
 	)
 
-	if *enableTUI {
+	if *enableTUI && !*dryRun {
 		uiManager = tui.NewManager(tui.Options{
 			InitialDebuggerEnabled: runtimeCfg.Component.DlvConfig.Enabled,
+			InitialWaitForDebugger: runtimeCfg.Component.DlvConfig.WaitForDebugger,
+			BuildEnabled:           runtimeCfg.Component.Build != nil,
+			HistoryEnabled:         runtimeCfg.Component.Kind != config.ComponentKindSync && *runtimeCfg.Component.History > 0,
 		})
 
 		log.SetOutput(uiManager.WorkLogWriter())
@@ -105,6 +142,11 @@ func main() {
 		runOpts.ContainerLogHandler = uiManager.PublishContainerLog
 		runOpts.ActionChan = uiManager.Actions()
 		runOpts.UpdateDebuggerState = uiManager.UpdateDebuggerState
+		runOpts.UpdateDebuggerStatus = uiManager.UpdateDebuggerStatus
+		runOpts.RunInteractive = uiManager.RunInteractive
+		runOpts.UpdateForwards = uiManager.UpdateForwards
+		runOpts.UpdateBuildStatus = uiManager.UpdateBuildStatus
+		runOpts.UpdateVersions = uiManager.UpdateVersions
 		runOpts.AutoConfirmPrompts = true
 
 		uiErrCh = make(chan error, 1)
@@ -116,6 +158,10 @@ func main() {
 	runErr := run(ctx, dockerMgr, runtimeCfg, exec, runOpts)
 	cancel()
 
+	if recorder != nil {
+		finishDryRun(recorder)
+	}
+
 	if uiManager != nil {
 		if err := <-uiErrCh; err != nil && !errors.Is(err, context.Canceled) {
 			log.Printf("TUI 結束: %v", err)
@@ -130,11 +176,103 @@ func main() {
 }
 
 type runOptions struct {
-	ContainerLogHandler func(string)
-	ActionChan          <-chan tui.Action
-	AutoConfirmPrompts  bool
-	UpdateDebuggerState func(bool)
-	Cancel              context.CancelFunc
+	ContainerLogHandler  func(string)
+	ActionChan           <-chan tui.Action
+	AutoConfirmPrompts   bool
+	UpdateDebuggerState  func(bool)
+	UpdateDebuggerStatus func(delve.Status)
+	RunInteractive       func(tui.InteractiveCommand)
+	UpdateForwards       func([]tui.ForwardStatus)
+	UpdateBuildStatus    func(tui.BuildStatus)
+	UpdateVersions       func([]tui.VersionInfo)
+	Cancel               context.CancelFunc
+	DryRun               bool
+	IDEEditors           []string // 啟動後產生 / 更新設定的 IDE
+}
+
+// usage 輸出命令列說明
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "用法: %s [選項] [子命令]\n\n", os.Args[0])
+	fmt.Fprintln(out, "子命令:")
+	fmt.Fprintln(out, "  (無)    啟動容器替換開發環境")
+	fmt.Fprintln(out, "  shell   進入目前開發容器的互動式 shell")
+	fmt.Fprintln(out, "  status  列出本機執行中的 session 與轉發連線統計")
+	fmt.Fprintln(out, "  ide     產生 / 更新 IDE 設定，可指定 vscode、goland（預設全部）")
+	fmt.Fprintln(out, "\n選項:")
+	flag.PrintDefaults()
+}
+
+// loadRuntimeConfig 載入配置並互動式選擇本次執行的組合，失敗時直接退出
+func loadRuntimeConfig() *config.RuntimeConfig {
+	// 載入配置
+	cfg, err := config.Load(*configFile)
+	if err != nil {
+		log.Fatalf("載入配置失敗: %v", err)
+	}
+
+	// 互動式選擇配置（支援多組配置）
+	runtimeCfg, err := cfg.InteractiveSelect()
+	if err != nil {
+		log.Fatalf("選擇配置失敗: %v", err)
+	}
+
+	if runtimeCfg.Component.TargetService == "" {
+		log.Fatal("必須指定目標服務名稱")
+	}
+
+	return runtimeCfg
+}
+
+// watchContainerEvents 監聽開發容器事件並輸出到工作日誌
+func watchContainerEvents(ctx context.Context, dockerMgr *docker.Manager, containerName string) {
+	events, errs := dockerMgr.Events(ctx, containerName)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				select {
+				case err := <-errs:
+					log.Printf("容器事件監聽停止: %v", err)
+				default:
+				}
+				return
+			}
+			switch event.Action {
+			case "die":
+				log.Printf("開發容器已停止 (exit code %s)", event.Actor.Attributes["exitCode"])
+			case "oom":
+				log.Println("開發容器記憶體不足 (OOM)")
+			}
+		}
+	}
+}
+
+// newCleanupContext 建立清理流程專用的 context
+// 不受主流程取消影響，但受 host 的 cleanup 逾時限制，避免清理卡住導致無法退出
+func newCleanupContext(rc *config.RuntimeConfig) (context.Context, context.CancelFunc) {
+	if rc.Host.Timeouts.Cleanup <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), rc.Host.Timeouts.Cleanup)
+}
+
+// inspectPlatform 讀取目標容器的平台，失敗時改用主機的 uname -m，再失敗時假設與本機相同
+func inspectPlatform(ctx context.Context, dockerMgr *docker.Manager, exec executor.Executor, original *docker.ContainerConfig) local.Platform {
+	platform, err := dockerMgr.InspectPlatform(ctx, original)
+	if err != nil {
+		log.Printf("讀取容器平台失敗: %v", err)
+		if output, err := exec.Execute(executor.WithReadOnly(ctx), "uname -m"); err == nil && strings.TrimSpace(output) != "" {
+			log.Printf("改用主機架構: %s", strings.TrimSpace(output))
+			return local.NewPlatform("linux", strings.TrimSpace(output), "", docker.LibcUnknown)
+		}
+		log.Println("假設目標平台與本機相同")
+		return local.NewPlatform(runtime.GOOS, runtime.GOARCH, "", docker.LibcUnknown)
+	}
+	log.Printf("目標容器平台: %s", platform)
+	return local.NewPlatform(platform.OS, platform.Arch, platform.Variant, platform.Libc)
 }
 
 func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfig, exec executor.Executor, opts runOptions) error {
@@ -149,61 +287,158 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 
 	// 1. 獲取原始容器配置
 	log.Println("獲取原始容器配置...")
-	originalContainer, err := dockerMgr.GetContainerConfig(rc.Component.TargetService)
+	originalContainer, err := dockerMgr.GetContainerConfig(ctx, rc.Component.TargetService)
 	if err != nil {
 		return fmt.Errorf("獲取容器配置失敗: %w", err)
 	}
 
-	// 2. 查找並上傳 dlv（如果啟用且配置）
+	// kind: sync 只同步目錄，不需要編譯、檢查與上傳執行檔
+	syncMode := rc.Component.Kind == config.ComponentKindSync
+	// build.strategy: remote 在執行主機上編譯，執行檔不經過本機
+	remoteBuild := !syncMode && rc.Component.Build != nil && rc.Component.Build.Strategy == config.BuildStrategyRemote
+
+	var platform local.Platform
+	if !syncMode {
+		platform = inspectPlatform(ctx, dockerMgr, exec, originalContainer)
+	}
+
+	// 設定了 build 時先編譯一次，確保部署的是目前的原始碼
+	var (
+		builder *buildRunner
+		remote  *remoteBuilder
+	)
+	if !syncMode && rc.Component.Build != nil {
+		if remoteBuild {
+			if remote, err = newRemoteBuilder(exec, dockerMgr, rc, platform); err != nil {
+				return fmt.Errorf("準備遠端編譯失敗: %w", err)
+			}
+		}
+		builder = newBuildRunner(rc.Component.Build, remote, opts.UpdateBuildStatus)
+		switch {
+		case remoteBuild && opts.DryRun:
+			log.Println("乾跑模式：略過遠端編譯")
+		case remoteBuild:
+			log.Println("在執行主機上編譯執行檔...")
+		default:
+			log.Println("編譯本地執行檔...")
+		}
+		if !(remoteBuild && opts.DryRun) {
+			if err := builder.Build(ctx); err != nil {
+				return fmt.Errorf("初始編譯失敗: %w", err)
+			}
+		}
+	}
+
+	// 檢查本地執行檔能否在目標容器執行與調試（遠端編譯的執行檔已符合目標平台，且不在本機）
+	var validator *binaryValidator
+	if !syncMode && !remoteBuild {
+		validator = newBinaryValidator(dockerMgr, rc, platform)
+		libProbeContainer := ""
+		if originalContainer.Running {
+			libProbeContainer = originalContainer.ContainerID
+		}
+		log.Println("檢查本地執行檔...")
+		if err := validator.Check(ctx, libProbeContainer); err != nil {
+			return fmt.Errorf("執行檔檢查未通過: %w", err)
+		}
+	}
+
+	// 2. 準備並上傳符合容器平台的 dlv（如果啟用）
 	var remoteDlvPath string
 	if rc.Component.DlvConfig != nil && rc.Component.DlvConfig.Enabled {
-		log.Println("查找本地 dlv...")
+		log.Println("準備 dlv...")
 
-		// 查找 dlv
-		localDlvPath, err := local.FindDlv(rc.Component.DlvConfig.LocalPath)
+		dlv, err := local.ProvisionDlv(ctx, local.DlvRequest{
+			ConfigPath:   rc.Component.DlvConfig.LocalPath,
+			Platform:     platform,
+			TargetBinary: rc.Component.LocalBinary,
+		})
 		if err != nil {
-			log.Printf("查找 dlv 失敗: %v", err)
-		} else if localDlvPath != "" {
-			log.Printf("找到 dlv: %s", localDlvPath)
+			log.Printf("準備 dlv 失敗: %v", err)
+			log.Println("將使用容器內的 dlv（如果有）")
+		} else {
+			log.Printf("找到 dlv: %s（%s）", dlv.Path, dlv.Source)
 
 			// 上傳 dlv
 			log.Println("上傳 dlv 到遠端...")
 			remoteDlvPath = rc.GetRemoteDlvPath()
-			if err := exec.UploadFile(localDlvPath, remoteDlvPath); err != nil {
+			if err := exec.UploadFile(ctx, dlv.Path, remoteDlvPath); err != nil {
 				log.Printf("上傳 dlv 失敗: %v", err)
 				remoteDlvPath = "" // 重置，使用容器內的 dlv
 			} else {
 				log.Printf("dlv 已上傳到遠端: %s", remoteDlvPath)
 			}
+		}
+	}
+
+	// 3. 上傳初始執行檔（kind: sync 則同步整個目錄）
+	var dirSync *artifactSync
+	if syncMode {
+		log.Printf("同步目錄 %s...", rc.Component.Sync.LocalDir)
+		dirSync = newDirSync(exec, rc)
+		if err := syncArtifacts(ctx, []*artifactSync{dirSync}); err != nil {
+			return err
+		}
+	}
+
+	// 保留部署過的執行檔供回滾（乾跑不記錄）
+	var history *deployHistory
+	if !syncMode && !opts.DryRun && *rc.Component.History > 0 {
+		history = newDeployHistory(ctx, exec, rc)
+	}
+
+	var deployed *state.Version
+	if !syncMode && !remoteBuild {
+		log.Println("上傳初始執行檔...")
+		if deployed, err = uploadLocalBinary(ctx, exec, rc, history); err != nil {
+			return fmt.Errorf("上傳執行檔失敗: %w", err)
+		}
+	}
+	if history != nil {
+		if v, err := history.RecordDeployed(ctx, deployed, remote); err != nil {
+			log.Printf("記錄部署版本失敗: %v", err)
 		} else {
-			log.Println("本地未找到 dlv，將使用容器內的 dlv（如果有）")
+			log.Printf("部署版本: %s", describeVersion(v))
 		}
 	}
 
-	// 3. 上傳初始執行檔
-	log.Println("上傳初始執行檔...")
-	if err := exec.UploadFile(rc.Component.LocalBinary, rc.GetRemoteBinaryPath()); err != nil {
-		return fmt.Errorf("上傳執行檔失敗: %w", err)
+	// 上傳 artifacts（需在建立開發容器前存在，否則掛載時會被建立為空目錄）
+	artifactSyncs := make([]*artifactSync, 0, len(rc.Component.Artifacts))
+	for _, art := range rc.Component.Artifacts {
+		artifactSyncs = append(artifactSyncs, newArtifactSync(exec, rc, art))
+	}
+	if len(artifactSyncs) > 0 {
+		log.Println("上傳 artifacts...")
+		if err := syncArtifacts(ctx, artifactSyncs); err != nil {
+			return err
+		}
 	}
 
 	initialScripts := ""
 	if rc.Component.InitialScripts != nil {
 		initialScripts = *rc.Component.InitialScripts
 	}
-	if err := exec.CreateScript(fmt.Sprintf("%s\nsh ./entry.sh", initialScripts), rc.GetRemoteInitScriptPath()); err != nil {
+	// 遠端模式沿用既有行為，由主機的 shell 展開 $ 變數、命令替換與 \n 等跳脫字元後再寫入
+	if initialScripts, err = exec.ExpandScript(ctx, initialScripts); err != nil {
+		return fmt.Errorf("展開初始腳本失敗: %w", err)
+	}
+	if err := exec.CreateScript(ctx, fmt.Sprintf("%s\nexec sh /app/entry.sh", initialScripts), rc.GetRemoteInitScriptPath()); err != nil {
 		return fmt.Errorf("上傳初始腳本失敗: %w", err)
 	}
 
 	// 4. 停止原始容器
 	log.Println("停止原始容器...")
-	if err := dockerMgr.StopContainer(rc.Component.TargetService); err != nil {
+	if err := dockerMgr.StopContainer(ctx, rc.Component.TargetService); err != nil {
 		return fmt.Errorf("停止容器失敗: %w", err)
 	}
 
 	// 確保退出時恢復原始容器
 	defer func() {
+		cleanupCtx, cancelCleanup := newCleanupContext(rc)
+		defer cancelCleanup()
+
 		log.Println("恢復原始容器...")
-		if err := dockerMgr.RestoreOriginalContainer(rc.Component.TargetService); err != nil {
+		if err := dockerMgr.RestoreOriginalContainer(cleanupCtx, rc.Component.TargetService); err != nil {
 			log.Printf("恢復原始容器失敗: %v", err)
 		} else {
 			log.Println("原始容器已恢復")
@@ -212,7 +447,7 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 
 	// 5. 建立開發容器
 	log.Println("建立開發容器...")
-	devContainer, err := dockerMgr.CreateDevContainer(originalContainer, remoteDlvPath)
+	devContainer, err := dockerMgr.CreateDevContainer(ctx, originalContainer, remoteDlvPath)
 	if err != nil {
 		// 檢查是否為容器名稱衝突錯誤
 		if strings.Contains(err.Error(), "發現殘留的開發容器") {
@@ -227,14 +462,14 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 
 			if shouldClean {
 				log.Println("清理殘留容器...")
-				if err := dockerMgr.RemoveDevContainerIfExists(rc.GetDevContainerName()); err != nil {
+				if err := dockerMgr.RemoveDevContainerIfExists(ctx, rc.GetDevContainerName()); err != nil {
 					return fmt.Errorf("清理殘留容器失敗: %w", err)
 				}
 				log.Println("殘留容器已清理")
 
 				// 重試建立開發容器
 				log.Println("重新建立開發容器...")
-				devContainer, err = dockerMgr.CreateDevContainer(originalContainer, remoteDlvPath)
+				devContainer, err = dockerMgr.CreateDevContainer(ctx, originalContainer, remoteDlvPath)
 				if err != nil {
 					return fmt.Errorf("建立開發容器失敗: %w", err)
 				}
@@ -249,63 +484,162 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 	// 確保退出時清理開發容器
 	defer func() {
 		log.SetOutput(os.Stderr)
+		cleanupCtx, cancelCleanup := newCleanupContext(rc)
+		defer cancelCleanup()
+
 		log.Println("清理開發容器...")
-		if err := dockerMgr.RemoveDevContainer(devContainer.Name); err != nil {
+		if err := dockerMgr.RemoveDevContainer(cleanupCtx, devContainer.Name); err != nil {
 			log.Printf("清理開發容器失敗: %v", err)
 		} else {
 			log.Println("開發容器已清理")
 		}
 	}()
 
+	// 建立反向轉發，讓開發容器啟動後就能連回本地服務
+	var forwards []*activeForward
+	if len(rc.Component.ReverseForwards) > 0 {
+		log.Println("建立反向轉發...")
+		reverse, err := startReverseForwards(ctx, exec, dockerMgr, rc)
+		if err != nil {
+			return err
+		}
+		defer closeForwards(reverse)
+		forwards = append(forwards, reverse...)
+	}
+
 	// 6. 啟動開發容器
 	log.Println("啟動開發容器...")
-	if err := dockerMgr.StartContainer(devContainer.Name); err != nil {
+	if err := dockerMgr.StartContainer(ctx, devContainer.Name); err != nil {
 		return fmt.Errorf("啟動開發容器失敗: %w", err)
 	}
 
-	// 7. 建立 SSH Tunnel (用於 Debugger) - 僅遠端模式
-	var tunnel executor.TunnelCloser
-	if exec.IsRemote() {
+	// 7. 建立 SSH Tunnel (用於 Debugger)
+	// 遠端模式一律需要；container 模式下 debugger 端口未發布到主機，本地模式也需要透過 docker exec 橋接
+	containerTarget := rc.Host.TunnelTarget == config.TunnelTargetContainer
+	debuggerPort := rc.Component.DlvConfig.Port
+	if exec.IsRemote() || containerTarget {
 		log.Println("建立 SSH Tunnel...")
-		tunnel, err = exec.CreateTunnel(rc.Component.DebuggerPort, rc.Component.DebuggerPort)
+		target := executor.TunnelTarget{Addr: fmt.Sprintf("localhost:%d", rc.Component.DebuggerPort)}
+		if containerTarget {
+			target = dockerMgr.PortTarget(originalContainer, rc.Component.DlvConfig.Port, "tcp")
+		}
+		local := executor.LocalPort{Port: rc.Component.DebuggerPort, Policy: rc.Component.DebuggerPortPolicy}
+		tunnel, err := exec.CreateTunnel(ctx, local, target)
 		if err != nil {
 			return fmt.Errorf("建立 SSH Tunnel 失敗: %w", err)
 		}
 		defer tunnel.Close()
-		log.Printf("Debugger 可在 localhost:%d 連接", rc.Component.DebuggerPort)
+		forwards = append(forwards, &activeForward{
+			name:      "debugger",
+			localPort: tunnel.ListenPort(),
+			target:    target.Addr,
+			tunnel:    tunnel,
+		})
+		logAllocatedPort("Debugger", local, tunnel.ListenPort())
+		debuggerPort = tunnel.ListenPort()
+		log.Printf("Debugger tunnel 已建立: localhost:%d → %s (%s)", debuggerPort, target.Addr, rc.Component.DlvConfig.Mode)
 	} else {
 		log.Println("本地模式，跳過建立 SSH Tunnel")
 	}
 
-	// 8. 啟動檔案監控
-	log.Println("啟動檔案監控...")
-	fileWatcher := local.NewFileWatcher(rc.Component.LocalBinary, func(path string) {
-		containerLock.Lock()
-		defer containerLock.Unlock()
+	// 建立額外的端口轉發（HTTP / gRPC / metrics 等）
+	if len(rc.Component.Forwards) > 0 {
+		log.Println("建立端口轉發...")
+		extra, err := startForwards(ctx, exec, dockerMgr, rc, originalContainer)
+		if err != nil {
+			return err
+		}
+		defer closeForwards(extra)
+		forwards = append(forwards, extra...)
+	}
+
+	// 乾跑到此為止：不監控檔案與日誌，直接進入清理流程以記錄清理操作
+	if opts.DryRun {
+		log.Println("乾跑完成，略過檔案監控與日誌監控")
+		return nil
+	}
+
+	// 啟動連入開發容器網路的代理
+	if rc.Component.Proxy.Enabled {
+		proxyForward, err := startProxy(ctx, dockerMgr, rc)
+		if err != nil {
+			return err
+		}
+		defer proxyForward.tunnel.Close()
+		forwards = append(forwards, proxyForward)
+	}
 
-		log.Printf("偵測到檔案更新: %s", path)
+	// 寫入狀態檔，讓 IDE 設定、腳本與 status 子命令查詢實際使用的端口與連線統計
+	var currentVersion *state.Version
+	if history != nil {
+		currentVersion = history.Current()
+	}
+	session, err := newSessionState(rc, devContainer.Name, forwards, currentVersion)
+	if err != nil {
+		log.Printf("寫入狀態檔失敗: %v", err)
+	} else {
+		log.Printf("狀態檔: %s", session.path)
+		defer session.remove()
+	}
+	go monitorForwards(ctx, forwards, session, opts.UpdateForwards)
 
-		// 上傳新檔案
-		log.Println("上傳新執行檔...")
-		if err := exec.UploadFile(rc.Component.LocalBinary, rc.GetRemoteBinaryPath()); err != nil {
-			log.Printf("上傳失敗: %v", err)
+	// 將版本紀錄推送到 TUI，並在狀態檔記錄目前的版本
+	publishVersions := func() {
+		if history == nil {
 			return
 		}
+		if session != nil {
+			if err := session.setVersion(history.Current()); err != nil {
+				log.Printf("更新狀態檔失敗: %v", err)
+			}
+		}
+		if opts.UpdateVersions != nil {
+			opts.UpdateVersions(versionInfos(history))
+		}
+	}
+	publishVersions()
+
+	// 透過 IDE 使用的地址確認 Delve 已可接受連線，每次重新部署後重新確認
+	debugger := newDebuggerMonitor(fmt.Sprintf("localhost:%d", debuggerPort), dockerMgr, rc, opts.UpdateDebuggerStatus)
+	debugger.Recheck(ctx)
+
+	// 以實際的 debugger 端口更新 IDE 設定
+	if len(opts.IDEEditors) > 0 && !syncMode {
+		if err := writeIDEConfigs(rc, debuggerPort, opts.IDEEditors); err != nil {
+			log.Printf("產生 IDE 設定失敗: %v", err)
+		}
+	}
+
+	// 載入新的執行檔或 artifacts：優先由容器內的 supervisor 只重新啟動程式，保留容器與 initial_scripts 的結果；
+	// supervisor 無回應時重啟容器，重啟失敗時重新建立；呼叫端需持有 containerLock
+	restartDevContainer := func() {
+		// 保存斷點，新的 dlv 就緒後恢復
+		debugger.Snapshot(ctx)
+
+		log.Println("重新啟動容器內的程式...")
+		launchedAt, err := dockerMgr.ReloadDevContainer(ctx, devContainer.Name)
+		if err == nil {
+			log.Println("程式已重新啟動，新版本已部署")
+			debugger.Relaunched(launchedAt)
+			debugger.Recheck(ctx)
+			return
+		}
+		log.Printf("重新啟動程式失敗: %v，改為重啟開發容器", err)
 
 		// 重啟容器
 		log.Println("重啟開發容器...")
-		if err := dockerMgr.RestartContainer(devContainer.Name); err != nil {
+		if err := dockerMgr.RestartContainer(ctx, devContainer.Name); err != nil {
 			log.Printf("重啟失敗: %v，嘗試重新創建容器...", err)
 
 			// 重啟失敗，嘗試重新創建容器
 			log.Println("移除舊容器...")
-			if err := dockerMgr.RemoveDevContainer(devContainer.Name); err != nil {
+			if err := dockerMgr.RemoveDevContainer(ctx, devContainer.Name); err != nil {
 				log.Printf("移除容器失敗: %v", err)
 				return
 			}
 
 			log.Println("重新創建開發容器...")
-			newDevContainer, err := dockerMgr.CreateDevContainer(originalContainer, remoteDlvPath)
+			newDevContainer, err := dockerMgr.CreateDevContainer(ctx, originalContainer, remoteDlvPath)
 			if err != nil {
 				log.Printf("創建容器失敗: %v", err)
 				return
@@ -313,23 +647,130 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 			devContainer = newDevContainer
 
 			log.Println("啟動新容器...")
-			if err := dockerMgr.StartContainer(devContainer.Name); err != nil {
+			if err := dockerMgr.StartContainer(ctx, devContainer.Name); err != nil {
 				log.Printf("啟動容器失敗: %v", err)
 				return
 			}
 
 			log.Println("容器已重新創建並啟動，新版本已部署")
+			debugger.Recheck(ctx)
 			return
 		}
 
 		log.Println("容器已重啟，新版本已部署")
-	})
+		debugger.Recheck(ctx)
+	}
 
-	if err := fileWatcher.Start(ctx); err != nil {
-		return fmt.Errorf("啟動檔案監控失敗: %w", err)
+	// 部署目前的 local_binary：檢查、上傳並重啟開發容器
+	// 遠端編譯已將執行檔放到部署位置，只需要重啟
+	deploy := func() {
+		containerLock.Lock()
+		defer containerLock.Unlock()
+		defer publishVersions()
+
+		var deployed *state.Version
+		if !remoteBuild {
+			if err := validator.Check(ctx, devContainer.Name); err != nil {
+				log.Printf("執行檔檢查未通過，略過本次部署: %v", err)
+				return
+			}
+
+			// 上傳新檔案
+			log.Println("上傳新執行檔...")
+			var err error
+			if deployed, err = uploadLocalBinary(ctx, exec, rc, history); err != nil {
+				log.Printf("上傳失敗: %v", err)
+				return
+			}
+		}
+
+		if history != nil {
+			if v, err := history.RecordDeployed(ctx, deployed, remote); err != nil {
+				log.Printf("記錄部署版本失敗: %v", err)
+			} else {
+				log.Printf("部署版本: %s", describeVersion(v))
+			}
+		}
+
+		restartDevContainer()
+	}
+
+	// 回滾到版本紀錄中的執行檔並重新啟動程式
+	rollback := func(hash string) error {
+		if history == nil {
+			return fmt.Errorf("未啟用版本紀錄")
+		}
+		containerLock.Lock()
+		defer containerLock.Unlock()
+		defer publishVersions()
+
+		v, err := history.Rollback(ctx, hash)
+		if v == nil {
+			return err
+		}
+		if err != nil {
+			log.Printf("更新版本紀錄失敗: %v", err)
+		}
+		log.Printf("回滾到版本 %s", describeVersion(v))
+		restartDevContainer()
+		return nil
+	}
+
+	// artifacts 變更時只同步 artifact 並重新啟動程式，不重新上傳執行檔
+	for _, s := range artifactSyncs {
+		err := watchArtifact(ctx, s, func(reason string) {
+			containerLock.Lock()
+			defer containerLock.Unlock()
+			log.Printf("%s，重新啟動程式", reason)
+			restartDevContainer()
+		})
+		if err != nil {
+			return fmt.Errorf("啟動 artifact '%s' 監控失敗: %w", s.artifact.Name, err)
+		}
+	}
+
+	// 8. 啟動檔案監控：kind: sync 監控同步目錄；設定了 build 時監控原始碼並在編譯成功後部署，否則監控 local_binary
+	if syncMode {
+		log.Printf("啟動目錄監控: %s", rc.Component.Sync.LocalDir)
+		err := watchArtifact(ctx, dirSync, func(reason string) {
+			containerLock.Lock()
+			defer containerLock.Unlock()
+			if sync := rc.Component.Sync; sync.OnChange == config.SyncOnChangeSignal {
+				log.Printf("%s，送出 SIG%s", reason, sync.Signal)
+				if err := dockerMgr.SignalContainer(ctx, devContainer.Name, sync.Signal); err != nil {
+					log.Printf("送出信號失敗: %v", err)
+				}
+				return
+			}
+			log.Printf("%s，重新啟動程式", reason)
+			restartDevContainer()
+		})
+		if err != nil {
+			return fmt.Errorf("啟動目錄監控失敗: %w", err)
+		}
+	} else if builder != nil {
+		build := rc.Component.Build
+		log.Printf("啟動原始碼監控: %s", build.WorkDir)
+		sourceWatcher := local.NewSourceWatcher(build.WorkDir, build.Watch, build.Ignore, build.Debounce, builder.OnSourceChange)
+		if err := sourceWatcher.Start(ctx); err != nil {
+			return fmt.Errorf("啟動原始碼監控失敗: %w", err)
+		}
+		go builder.Run(ctx, deploy)
+	} else {
+		log.Println("啟動檔案監控...")
+		fileWatcher := local.NewFileWatcher(rc.Component.LocalBinary, func(path string) {
+			log.Printf("偵測到檔案更新: %s", path)
+			deploy()
+		})
+		if err := fileWatcher.Start(ctx); err != nil {
+			return fmt.Errorf("啟動檔案監控失敗: %w", err)
+		}
 	}
 
 	toggleDebugger := func(enabled bool) error {
+		if syncMode && enabled {
+			return fmt.Errorf("kind: sync 的 component 不支援 debugger")
+		}
 		if rc.Component.DlvConfig.Enabled == enabled {
 			state := "關閉"
 			if enabled {
@@ -344,24 +785,26 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 			state = "開啟"
 		}
 		log.Printf("切換 debugger 為%s...", state)
+		debugger.Snapshot(ctx)
 		rc.Component.DlvConfig.Enabled = enabled
 		log.Println("重新建立開發容器以套用 debugger 設定...")
 
-		if err := dockerMgr.RemoveDevContainer(devContainer.Name); err != nil {
+		if err := dockerMgr.RemoveDevContainer(ctx, devContainer.Name); err != nil {
 			return fmt.Errorf("移除容器失敗: %w", err)
 		}
 
-		newDevContainer, err := dockerMgr.CreateDevContainer(originalContainer, remoteDlvPath)
+		newDevContainer, err := dockerMgr.CreateDevContainer(ctx, originalContainer, remoteDlvPath)
 		if err != nil {
 			return fmt.Errorf("重新建立容器失敗: %w", err)
 		}
 		devContainer = newDevContainer
 
-		if err := dockerMgr.StartContainer(devContainer.Name); err != nil {
+		if err := dockerMgr.StartContainer(ctx, devContainer.Name); err != nil {
 			return fmt.Errorf("啟動容器失敗: %w", err)
 		}
 
 		log.Println("Debugger 模式已更新並重新啟動容器")
+		debugger.Recheck(ctx)
 		return nil
 	}
 
@@ -385,6 +828,35 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 						} else if opts.UpdateDebuggerState != nil {
 							opts.UpdateDebuggerState(action.Enabled)
 						}
+					case tui.ActionToggleWaitForDebugger:
+						containerLock.Lock()
+						debugger.SetWaitForDebugger(action.Enabled)
+						containerLock.Unlock()
+					case tui.ActionResumeDebugger:
+						if err := debugger.Resume(ctx); err != nil {
+							log.Printf("繼續執行目標程式失敗: %v", err)
+						} else {
+							log.Println("已繼續執行目標程式")
+						}
+					case tui.ActionRebuild:
+						if builder == nil {
+							log.Println("未設定 build，請自行編譯 local_binary")
+							continue
+						}
+						builder.Trigger("手動重新編譯")
+					case tui.ActionRollback:
+						if err := rollback(action.Version); err != nil {
+							log.Printf("回滾失敗: %v", err)
+						}
+					case tui.ActionOpenShell:
+						if opts.RunInteractive == nil {
+							continue
+						}
+						containerLock.Lock()
+						containerName := devContainer.Name
+						containerLock.Unlock()
+						log.Printf("開啟容器 %s 的互動式 shell...", containerName)
+						opts.RunInteractive(shell.NewCommand(ctx, exec, dockerMgr, containerName))
 					case tui.ActionQuit:
 						if opts.Cancel != nil {
 							opts.Cancel()
@@ -401,7 +873,10 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 		log.Printf("日誌將寫入文件: %s", *rc.Component.LogFile)
 	}
 
-	logFollower := docker.NewLogFollower(exec, dockerMgr, devContainer.Name, rc, opts.ContainerLogHandler)
+	// 監聽開發容器事件，提示容器退出與 OOM
+	go watchContainerEvents(ctx, dockerMgr, rc.GetDevContainerName())
+
+	logFollower := docker.NewLogFollower(dockerMgr, devContainer.Name, rc, opts.ContainerLogHandler)
 	go func() {
 		if err := logFollower.Start(ctx); err != nil && err != context.Canceled {
 			log.Printf("日誌監控停止: %v", err)
@@ -410,7 +885,14 @@ func run(ctx context.Context, dockerMgr *docker.Manager, rc *config.RuntimeConfi
 
 	log.Println("開發環境已就緒！")
 	log.Println("   - 按 Ctrl+C 退出並清理")
-	log.Println("   - 修改並編譯二進制檔案會自動部署")
+	switch {
+	case syncMode:
+		log.Println("   - 修改目錄內的檔案會自動同步")
+	case builder != nil:
+		log.Println("   - 修改原始碼會自動編譯並部署")
+	default:
+		log.Println("   - 修改並編譯二進制檔案會自動部署")
+	}
 	log.Println("   - 容器日誌將顯示在下方")
 	log.Println("==========================================")
 
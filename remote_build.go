@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/user"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/config"
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+	"github.com/laysdragon/go-docker-dev-swap/internal/ide"
+	"github.com/laysdragon/go-docker-dev-swap/internal/local"
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
+)
+
+// remoteBuildOutputDir 建置容器內輸出執行檔的目錄，對應執行主機的 GetRemoteBuildDir()
+const remoteBuildOutputDir = "/dev-swap-build"
+
+// remoteSourceRoot 建置容器內 Go 模組的掛載位置，不使用本機路徑（Windows 的 C:/… 不是合法的容器路徑）
+const remoteSourceRoot = "/src"
+
+// goCacheVolumes 返回建置容器的模組與編譯快取，以 named volume 保存在執行主機上供之後的編譯重用
+// volume 名稱帶有使用者名稱，共用主機上不同使用者的快取互不影響
+func goCacheVolumes(rc *config.RuntimeConfig) []string {
+	prefix := "docker-dev-swap-" + volumeNamePart(cacheOwner(rc))
+	return []string{
+		prefix + "-gomod:/go/pkg/mod",
+		prefix + "-gocache:/root/.cache/go-build",
+	}
+}
+
+// cacheOwner 返回快取所屬的使用者：遠端模式為 SSH 使用者，本地模式為目前登入的使用者
+func cacheOwner(rc *config.RuntimeConfig) string {
+	if rc.Mode == "remote" && rc.Host.User != "" {
+		return rc.Host.User
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "default"
+}
+
+// volumeNamePart 將字串轉換為 volume 名稱可用的字元（英數字、_、.、-），例如 DOMAIN\user → domain_user
+func volumeNamePart(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '.', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+}
+
+// remoteBuilder 實作 build.strategy: remote
+// 將 Go 模組同步到執行主機，在與目標容器相同平台與 libc 的建置容器中編譯，
+// 再將執行檔移到 GetRemoteBinaryPath()，不需要在本機交叉編譯與上傳
+type remoteBuilder struct {
+	exec      executor.Executor
+	dockerMgr *docker.Manager
+	rc        *config.RuntimeConfig
+	source    *artifactSync
+	spec      docker.BuildSpec
+	output    string    // 執行主機上建置容器輸出的執行檔
+	ready     bool      // 輸出目錄是否已建立
+	builtAt   time.Time // 最近一次編譯成功的時間
+	revision  string    // 最近一次編譯時本地原始碼的 git revision
+	modified  bool      // 最近一次編譯時本地原始碼是否有未提交的變更
+}
+
+func newRemoteBuilder(exec executor.Executor, dockerMgr *docker.Manager, rc *config.RuntimeConfig, platform local.Platform) (*remoteBuilder, error) {
+	build := rc.Component.Build
+	module, err := local.FindModule(build.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+
+	image := build.Image
+	if image == "" {
+		image = builderImage(module.GoVersion, platform.Libc)
+	}
+
+	workDir, err := filepath.Rel(module.Root, build.WorkDir)
+	if err != nil {
+		return nil, fmt.Errorf("無法取得 build.workdir 在模組中的相對路徑: %w", err)
+	}
+
+	// 原始碼掛載在固定的 remoteSourceRoot，IDE 設定以 substitutePath 對應回本機路徑（見 remoteSourceInfo）
+	ignore := []string{"**/.*/**", "**/node_modules/**"}
+	if rel, err := filepath.Rel(module.Root, rc.Component.LocalBinary); err == nil && !strings.HasPrefix(rel, "..") {
+		ignore = append(ignore, filepath.ToSlash(rel))
+	}
+	source := &artifactSync{
+		exec:     exec,
+		artifact: config.Artifact{Name: "src", LocalPath: module.Root, ContainerPath: remoteSourceRoot},
+		remote:   rc.GetRemoteSourcePath(),
+		label:    "原始碼",
+		ignore:   ignore,
+		synced:   make(map[string]syncedFile),
+	}
+
+	name := path.Base(rc.GetRemoteBinaryPath())
+	env := []string{"DEV_SWAP_OUTPUT=" + path.Join(remoteBuildOutputDir, name)}
+	keys := make([]string, 0, len(build.Env))
+	for k := range build.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, k+"="+build.Env[k])
+	}
+
+	binds := []string{
+		fmt.Sprintf("%s:%s", rc.GetRemoteSourcePath(), remoteSourceRoot),
+		fmt.Sprintf("%s:%s", rc.GetRemoteBuildDir(), remoteBuildOutputDir),
+	}
+	return &remoteBuilder{
+		exec:      exec,
+		dockerMgr: dockerMgr,
+		rc:        rc,
+		source:    source,
+		spec: docker.BuildSpec{
+			Image:      image,
+			Platform:   platform.String(),
+			Command:    withBuildInfo(build.Command),
+			WorkingDir: path.Join(remoteSourceRoot, filepath.ToSlash(workDir)),
+			Env:        env,
+			Binds:      append(binds, goCacheVolumes(rc)...),
+		},
+		output: path.Join(rc.GetRemoteBuildDir(), name),
+	}, nil
+}
+
+// remoteSourceInfo 返回遠端編譯的原始碼對應：執行檔中的路徑位於 remoteSourceRoot，本機為模組根目錄
+// 編譯命令使用 -trimpath 時執行檔中沒有絕對路徑，產生的 substitutePath 不會生效
+func remoteSourceInfo(build *config.BuildConfig) (*ide.SourceInfo, error) {
+	module, err := local.FindModule(build.WorkDir)
+	if err != nil {
+		return nil, err
+	}
+	return &ide.SourceInfo{BuildRoot: remoteSourceRoot, Workspace: module.Root}, nil
+}
+
+// builderImage 依 go.mod 的 Go 版本與目標容器的 libc 選擇官方 golang 映像
+// musl 使用 alpine 版本，glibc 或無法判斷時使用 Debian 版本
+func builderImage(goVersion, libc string) string {
+	tag := goVersion
+	if libc == docker.LibcMusl {
+		if tag == "" {
+			tag = "alpine"
+		} else {
+			tag += "-alpine"
+		}
+	}
+	if tag == "" {
+		return "golang"
+	}
+	return "golang:" + tag
+}
+
+// withBuildInfo 在編譯成功後以 go version -m 將執行檔的 buildinfo 寫到輸出旁，供版本紀錄讀取
+// 自訂映像沒有 go 命令時略過，不影響編譯結果
+func withBuildInfo(command string) string {
+	return fmt.Sprintf("rm -f \"$DEV_SWAP_OUTPUT.buildinfo\"\n(\n%s\n) && { go version -m \"$DEV_SWAP_OUTPUT\" > \"$DEV_SWAP_OUTPUT.buildinfo\" 2>/dev/null || true; }", command)
+}
+
+// Image 返回使用的建置映像
+func (r *remoteBuilder) Image() string {
+	return r.spec.Image
+}
+
+// Build 同步原始碼並在建置容器中編譯，成功後將執行檔移到部署位置
+// 編譯輸出即時寫入工作日誌，同時保留在結果中
+func (r *remoteBuilder) Build(ctx context.Context) (*local.BuildResult, error) {
+	start := time.Now()
+	result := &local.BuildResult{}
+
+	// 同步時略過了 .git，建置容器中的 go build 無法取得 VCS 資訊，改在同步前讀取本地的狀態
+	revision, modified := local.VCSState(ctx, r.source.artifact.LocalPath)
+	uploaded, removed, err := r.source.Sync(ctx)
+	if err != nil {
+		result.Duration = time.Since(start)
+		return result, fmt.Errorf("同步原始碼失敗: %w", err)
+	}
+	if uploaded > 0 || removed > 0 {
+		log.Printf("原始碼已同步：上傳 %d 個、刪除 %d 個檔案", uploaded, removed)
+	}
+
+	if !r.ready {
+		if _, err := r.exec.Execute(ctx, "mkdir -p -- "+shellQuote(r.rc.GetRemoteBuildDir())); err != nil {
+			result.Duration = time.Since(start)
+			return result, fmt.Errorf("建立遠端輸出目錄失敗: %w", err)
+		}
+		r.ready = true
+	}
+
+	var lines []string
+	err = r.dockerMgr.RunBuild(ctx, r.spec, func(line string) {
+		log.Printf("  %s", line)
+		lines = append(lines, line)
+	})
+	result.Output = strings.Join(lines, "\n")
+	result.Duration = time.Since(start)
+	if err != nil {
+		return result, err
+	}
+
+	// 以 rename 取代執行檔：運行中的開發容器仍使用舊檔案，重啟後才載入新版本
+	move := fmt.Sprintf("mv -f -- %s %s", shellQuote(r.output), shellQuote(r.rc.GetRemoteBinaryPath()))
+	if _, err := r.exec.Execute(ctx, move); err != nil {
+		return result, fmt.Errorf("移動編譯結果失敗: %w", err)
+	}
+	r.builtAt = time.Now()
+	r.revision, r.modified = revision, modified
+	return result, nil
+}
+
+// Version 返回最近一次編譯結果的版本資訊：hash 在執行主機上計算，Go 版本來自建置容器輸出的 buildinfo
+// buildinfo 沒有 VCS 資訊時（原始碼同步略過了 .git）使用編譯前讀取的本地 git 狀態
+func (r *remoteBuilder) Version(ctx context.Context) (*state.Version, error) {
+	ctx = executor.WithReadOnly(ctx)
+	output, err := r.exec.Execute(ctx, "sha256sum -- "+shellQuote(r.rc.GetRemoteBinaryPath()))
+	if err != nil {
+		return nil, fmt.Errorf("計算執行檔 hash 失敗: %w", err)
+	}
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("無法解析 sha256sum 輸出: %q", output)
+	}
+	v := &state.Version{Hash: fields[0], BuildTime: r.builtAt}
+
+	output, err = r.exec.Execute(ctx, fmt.Sprintf("cat -- %s 2>/dev/null || true", shellQuote(r.output+".buildinfo")))
+	if err == nil {
+		if info, err := parseGoVersionM(output); err == nil {
+			applyBuildInfo(v, info)
+		}
+	}
+	if v.Revision == "" {
+		v.Revision, v.Modified = r.revision, r.modified
+	}
+	return v, nil
+}
+
+// parseGoVersionM 將 go version -m 的輸出轉換為 debug.BuildInfo
+// 第一行為「路徑: Go 版本」，其餘行與 BuildInfo.String() 的格式相同但以 tab 縮排，ParseBuildInfo 不解析 Go 版本需另外設定
+func parseGoVersionM(output string) (*debug.BuildInfo, error) {
+	first, rest, _ := strings.Cut(strings.TrimSpace(output), "\n")
+	i := strings.LastIndex(first, ": ")
+	if i < 0 {
+		return nil, fmt.Errorf("無法解析 go version -m 輸出")
+	}
+	var lines []string
+	for _, line := range strings.Split(rest, "\n") {
+		if line = strings.TrimPrefix(strings.TrimRight(line, "\r"), "\t"); line != "" {
+			lines = append(lines, line+"\n")
+		}
+	}
+	info, err := debug.ParseBuildInfo(strings.Join(lines, ""))
+	if err != nil {
+		return nil, err
+	}
+	info.GoVersion = strings.TrimSpace(first[i+2:])
+	return info, nil
+}
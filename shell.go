@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/docker"
+	"github.com/laysdragon/go-docker-dev-swap/internal/executor"
+	"github.com/laysdragon/go-docker-dev-swap/internal/shell"
+)
+
+// runShellCommand 實作 shell 子命令：進入目前運行中的開發容器
+func runShellCommand() {
+	rc := loadRuntimeConfig()
+
+	exec, err := executor.NewExecutor(rc)
+	if err != nil {
+		log.Fatalf("建立 Executor 失敗: %v", err)
+	}
+	defer exec.Close()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer cancel()
+
+	dockerMgr := docker.NewManager(exec, rc)
+	containerName := rc.GetDevContainerName()
+
+	running, err := dockerMgr.CheckContainerRunning(ctx, containerName)
+	if err != nil {
+		log.Fatalf("檢查開發容器失敗: %v", err)
+	}
+	if !running {
+		log.Fatalf("開發容器 %s 未運行，請先啟動 docker-dev-swap", containerName)
+	}
+
+	log.Printf("進入開發容器 %s ...", containerName)
+	if err := shell.NewCommand(ctx, exec, dockerMgr, containerName).Run(); err != nil {
+		exec.Close()
+		log.Fatalf("Shell 執行失敗: %v", err)
+	}
+	os.Stdout.WriteString("\r\n")
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/laysdragon/go-docker-dev-swap/internal/state"
+)
+
+// runStatusCommand 實作 status 子命令：列出本機上執行中的 session 與轉發統計
+// 直接讀取狀態檔，不需要選擇配置，也不會連接主機
+func runStatusCommand() {
+	states, err := state.List()
+	if err != nil {
+		log.Fatalf("讀取狀態檔失敗: %v", err)
+	}
+	if len(states) == 0 {
+		fmt.Println("沒有執行中的 session")
+		return
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartedAt.Before(states[j].StartedAt)
+	})
+
+	for i, st := range states {
+		if i > 0 {
+			fmt.Println()
+		}
+		printSessionStatus(st)
+	}
+}
+
+// printSessionStatus 輸出單一 session 的狀態與轉發統計
+func printSessionStatus(st *state.State) {
+	status := "執行中"
+	if !st.Alive() {
+		status = "已結束（殘留的狀態檔）"
+	}
+	fmt.Printf("%s@%s  專案: %s  模式: %s  PID: %d  %s\n", st.Component, st.Host, st.Project, st.Mode, st.PID, status)
+	fmt.Printf("開發容器: %s  啟動於: %s", st.DevContainer, st.StartedAt.Format(time.DateTime))
+	if !st.UpdatedAt.IsZero() {
+		fmt.Printf("  更新於: %s", st.UpdatedAt.Format(time.DateTime))
+	}
+	fmt.Println()
+	if v := st.Version; v != nil {
+		fmt.Printf("目前版本: %s", v.ShortHash())
+		if rev := v.ShortRevision(); rev != "" {
+			fmt.Printf("  revision: %s", rev)
+		}
+		fmt.Printf("  編譯於: %s  部署於: %s\n", v.BuildTime.Format(time.DateTime), v.DeployedAt.Format(time.DateTime))
+	}
+
+	if len(st.Forwards) == 0 {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "名稱\t本地端口\t目標\t連線\t累計\t送出\t接收\t失敗\t最近錯誤")
+	for _, fwd := range st.Forwards {
+		target := fwd.Target
+		if fwd.Reverse {
+			target = "← " + target
+		}
+		if fwd.Direct {
+			fmt.Fprintf(w, "%s\t%d\t%s\t-\t-\t-\t-\t-\t(直連)\n", fwd.Name, fwd.LocalPort, target)
+			continue
+		}
+		lastError := "-"
+		if fwd.LastError != "" {
+			lastError = fwd.LastError
+			if fwd.LastErrorAt != nil {
+				lastError = fmt.Sprintf("%s %s", fwd.LastErrorAt.Format(time.TimeOnly), fwd.LastError)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%d\t%d\t%s\t%s\t%d\t%s\n",
+			fwd.Name, fwd.LocalPort, target, fwd.Active, fwd.Total,
+			state.FormatBytes(fwd.BytesOut), state.FormatBytes(fwd.BytesIn), fwd.DialErrors, lastError)
+	}
+	w.Flush()
+}